@@ -0,0 +1,477 @@
+/*
+Package backup exports a Toggl workspace to a local archive and restores it.
+
+An archive is a gzipped tarball holding a manifest.json file and one JSON lines
+file per resource type (clients.jsonl, projects.jsonl, ...). The manifest
+records the archive format version and the SHA-256 checksum of every file,
+which are verified before anything is restored.
+
+Restores go through the Target interface, implemented by *toggl.Session, so an
+archive can be restored into an empty workspace or into a fake server. The
+progress of a restore is tracked in a Progress value, which can be saved and
+given back to resume an interrupted restore.
+*/
+package backup
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/leucos/go-toggl"
+	"github.com/leucos/go-toggl/resource"
+)
+
+// Version is the archive format version written by Export.
+const Version = 1
+
+const manifestName = "manifest.json"
+
+// Manifest describes the content of an archive.
+type Manifest struct {
+	Version     int                 `json:"version"`
+	WorkspaceID int                 `json:"workspace_id"`
+	CreatedAt   time.Time           `json:"created_at"`
+	Since       time.Time           `json:"since"`
+	Until       time.Time           `json:"until"`
+	Files       map[string]FileInfo `json:"files"`
+}
+
+// FileInfo describes a file of an archive.
+type FileInfo struct {
+	Type     string `json:"type"`
+	Records  int    `json:"records"`
+	Checksum string `json:"sha256"`
+}
+
+// ExportOptions tunes Export.
+type ExportOptions struct {
+	// Since and Until restrict the exported time entries. They default to
+	// the whole history of the workspace.
+	Since time.Time
+	Until time.Time
+	// Period restricts the exported time entries instead of Since and
+	// Until, unless it is zero.
+	Period toggl.Period
+}
+
+// exporters list the records of a resource type in a workspace.
+var exporters = map[resource.Type]func(s *toggl.Session, wid int, opts ExportOptions) ([]any, error){
+	resource.Clients: func(s *toggl.Session, wid int, _ ExportOptions) ([]any, error) {
+		return records(s.GetClients(wid))
+	},
+	resource.Projects: func(s *toggl.Session, wid int, _ ExportOptions) ([]any, error) {
+		return records(s.GetProjects(wid, toggl.AllProjects))
+	},
+	resource.ProjectUsers: func(s *toggl.Session, wid int, _ ExportOptions) ([]any, error) {
+		return records(s.GetProjectUsers(wid, 0))
+	},
+	resource.Tags: func(s *toggl.Session, wid int, _ ExportOptions) ([]any, error) {
+		return records(s.GetTags(wid))
+	},
+	resource.Tasks: func(s *toggl.Session, wid int, _ ExportOptions) ([]any, error) {
+		return records(s.GetTasks(wid))
+	},
+	resource.TimeEntries: func(s *toggl.Session, wid int, opts ExportOptions) ([]any, error) {
+		var list []any
+		err := s.ForEachDetailedTimeEntry(wid, opts.Since, opts.Until, func(e toggl.DetailedTimeEntry) error {
+			list = append(list, e)
+			return nil
+		})
+		return list, err
+	},
+	resource.Users: func(s *toggl.Session, wid int, _ ExportOptions) ([]any, error) {
+		return records(s.GetWorkspaceUsers(wid))
+	},
+}
+
+func records[T any](list []T, err error) ([]any, error) {
+	if err != nil {
+		return nil, err
+	}
+	out := make([]any, len(list))
+	for i, v := range list {
+		out[i] = v
+	}
+	return out, nil
+}
+
+// fileName returns the name of the archive file holding a resource type.
+func fileName(rt resource.Type) string {
+	return rt.String() + ".jsonl"
+}
+
+// resourceTypes returns the resource types in a stable order.
+func resourceTypes() []resource.Type {
+	types := make([]resource.Type, 0, len(resource.TypeMap))
+	for rt := range resource.TypeMap {
+		types = append(types, rt)
+	}
+	slices.Sort(types)
+	return types
+}
+
+// Export writes an archive of a workspace to w.
+func Export(session *toggl.Session, wid int, w io.Writer, opts ExportOptions) (Manifest, error) {
+	if !opts.Period.IsZero() {
+		opts.Since, opts.Until = opts.Period.Start, opts.Period.Last()
+	}
+	if opts.Since.IsZero() {
+		opts.Since = toggl.Epoch
+	}
+	if opts.Until.IsZero() {
+		opts.Until = time.Now()
+	}
+
+	manifest := Manifest{
+		Version:     Version,
+		WorkspaceID: wid,
+		CreatedAt:   time.Now().UTC(),
+		Since:       opts.Since,
+		Until:       opts.Until,
+		Files:       make(map[string]FileInfo),
+	}
+
+	files := make(map[string][]byte)
+	for _, rt := range resourceTypes() {
+		export, ok := exporters[rt]
+		if !ok {
+			continue
+		}
+
+		list, err := export(session, wid, opts)
+		if err != nil {
+			return manifest, fmt.Errorf("error exporting %s: %v", rt, err)
+		}
+
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		for _, v := range list {
+			if err := enc.Encode(v); err != nil {
+				return manifest, fmt.Errorf("error encoding %s: %v", rt, err)
+			}
+		}
+
+		name := fileName(rt)
+		files[name] = buf.Bytes()
+		manifest.Files[name] = FileInfo{Type: rt.String(), Records: len(list), Checksum: checksum(buf.Bytes())}
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return manifest, err
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	write := func(name string, data []byte) error {
+		hdr := &tar.Header{Name: name, Mode: 0o600, Size: int64(len(data)), ModTime: manifest.CreatedAt}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+
+	if err := write(manifestName, data); err != nil {
+		return manifest, err
+	}
+	for _, rt := range resourceTypes() {
+		name := fileName(rt)
+		if data, ok := files[name]; ok {
+			if err := write(name, data); err != nil {
+				return manifest, err
+			}
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return manifest, err
+	}
+	return manifest, gz.Close()
+}
+
+// ExportFile writes an archive of a workspace to a file.
+func ExportFile(session *toggl.Session, wid int, path string, opts ExportOptions) (Manifest, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	manifest, err := Export(session, wid, f, opts)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return manifest, err
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Archive is the verified content of an archive.
+type Archive struct {
+	Manifest Manifest
+	files    map[string][]byte
+}
+
+// Read reads an archive and verifies its checksums.
+func Read(r io.Reader) (*Archive, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("error opening archive: %v", err)
+	}
+	defer gz.Close()
+
+	a := &Archive{files: make(map[string][]byte)}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading archive: %v", err)
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %v", hdr.Name, err)
+		}
+		a.files[hdr.Name] = data
+	}
+
+	data, ok := a.files[manifestName]
+	if !ok {
+		return nil, errors.New("archive has no manifest")
+	}
+	if err := json.Unmarshal(data, &a.Manifest); err != nil {
+		return nil, fmt.Errorf("error decoding manifest: %v", err)
+	}
+	if a.Manifest.Version > Version {
+		return nil, fmt.Errorf("unsupported archive version %d", a.Manifest.Version)
+	}
+
+	for name, info := range a.Manifest.Files {
+		data, ok := a.files[name]
+		if !ok {
+			return nil, fmt.Errorf("archive is missing %s", name)
+		}
+		if sum := checksum(data); sum != info.Checksum {
+			return nil, fmt.Errorf("checksum mismatch for %s: expected %s, got %s", name, info.Checksum, sum)
+		}
+	}
+
+	return a, nil
+}
+
+// ReadFile reads an archive file and verifies its checksums.
+func ReadFile(path string) (*Archive, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Read(f)
+}
+
+// decode calls fn with every record of a resource type.
+func decode[T any](a *Archive, rt resource.Type, fn func(T) error) error {
+	data, ok := a.files[fileName(rt)]
+	if !ok {
+		return nil
+	}
+
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		var v T
+		if err := json.Unmarshal(sc.Bytes(), &v); err != nil {
+			return fmt.Errorf("error decoding %s line %d: %v", fileName(rt), line, err)
+		}
+		if err := fn(v); err != nil {
+			return err
+		}
+	}
+
+	return sc.Err()
+}
+
+// Target is where archives are restored. It is implemented by
+// *toggl.Session.
+type Target interface {
+	CreateClient(name string, wid int) (toggl.Client, error)
+	CreateProjectWithOptions(wid int, opts *toggl.ProjectOptions) (toggl.Project, error)
+	CreateTask(task toggl.Task) (toggl.Task, error)
+	CreateTag(name string, wid int) (toggl.Tag, error)
+	CreateTimeEntry(timer toggl.TimeEntry) (toggl.TimeEntry, error)
+}
+
+// Progress maps the IDs of archived resources to the IDs of the restored
+// ones, by resource type name.
+type Progress map[string]map[int]int
+
+// LoadProgress reads progress saved by Save. A missing file yields an empty
+// progress.
+func LoadProgress(path string) (Progress, error) {
+	p := Progress{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return p, json.Unmarshal(data, &p)
+}
+
+// Save writes the progress to a file.
+func (p Progress) Save(path string) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0o600)
+}
+
+func (p Progress) lookup(rt resource.Type, id int) (int, bool) {
+	newID, ok := p[rt.String()][id]
+	return newID, ok
+}
+
+func (p Progress) set(rt resource.Type, id, newID int) {
+	if p[rt.String()] == nil {
+		p[rt.String()] = make(map[int]int)
+	}
+	p[rt.String()][id] = newID
+}
+
+// Restore recreates the clients, projects, tasks, tags and time entries of an
+// archive in the workspace wid. Users and project memberships are not
+// restored, as users have to be invited. Resources already present in
+// progress are skipped, and progress is updated as they are restored, so that
+// an interrupted restore can be resumed. A new restore starts with an empty
+// Progress{}.
+func Restore(target Target, a *Archive, wid int, progress Progress) error {
+	if progress == nil {
+		return errors.New("restore progress must not be nil")
+	}
+
+	err := decode(a, resource.Clients, func(c toggl.Client) error {
+		if _, done := progress.lookup(resource.Clients, c.ID); done {
+			return nil
+		}
+		created, err := target.CreateClient(c.Name, wid)
+		if err != nil {
+			return fmt.Errorf("error restoring client %q: %v", c.Name, err)
+		}
+		progress.set(resource.Clients, c.ID, created.ID)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = decode(a, resource.Projects, func(p toggl.Project) error {
+		if _, done := progress.lookup(resource.Projects, p.ID); done {
+			return nil
+		}
+
+		opts := p.CopyOptions()
+		if p.Cid != nil {
+			if cid, ok := progress.lookup(resource.Clients, *p.Cid); ok {
+				opts.WithClient(cid)
+			}
+		}
+
+		created, err := target.CreateProjectWithOptions(wid, opts)
+		if err != nil {
+			return fmt.Errorf("error restoring project %q: %v", p.Name, err)
+		}
+		progress.set(resource.Projects, p.ID, created.ID)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = decode(a, resource.Tasks, func(t toggl.Task) error {
+		if _, done := progress.lookup(resource.Tasks, t.ID); done {
+			return nil
+		}
+		pid, ok := progress.lookup(resource.Projects, t.Pid)
+		if !ok {
+			return fmt.Errorf("error restoring task %q: project %d was not restored", t.Name, t.Pid)
+		}
+
+		created, err := target.CreateTask(toggl.Task{
+			Wid:              wid,
+			Pid:              pid,
+			Name:             t.Name,
+			Active:           t.Active,
+			EstimatedSeconds: t.EstimatedSeconds,
+		})
+		if err != nil {
+			return fmt.Errorf("error restoring task %q: %v", t.Name, err)
+		}
+		progress.set(resource.Tasks, t.ID, created.ID)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = decode(a, resource.Tags, func(t toggl.Tag) error {
+		if _, done := progress.lookup(resource.Tags, t.ID); done {
+			return nil
+		}
+		created, err := target.CreateTag(t.Name, wid)
+		if err != nil {
+			return fmt.Errorf("error restoring tag %q: %v", t.Name, err)
+		}
+		progress.set(resource.Tags, t.ID, created.ID)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return decode(a, resource.TimeEntries, func(e toggl.DetailedTimeEntry) error {
+		if _, done := progress.lookup(resource.TimeEntries, e.ID); done {
+			return nil
+		}
+
+		entry := e.TimeEntry(wid)
+		// the users of the backup may not be members of the target workspace
+		entry.UserID = 0
+		entry.Pid, entry.Tid = nil, nil
+		if pid, ok := progress.lookup(resource.Projects, e.Pid); ok {
+			entry.Pid = &pid
+		}
+		if tid, ok := progress.lookup(resource.Tasks, e.Tid); ok {
+			entry.Tid = &tid
+		}
+
+		created, err := target.CreateTimeEntry(entry)
+		if err != nil {
+			return fmt.Errorf("error restoring time entry %d: %v", e.ID, err)
+		}
+		progress.set(resource.TimeEntries, e.ID, created.ID)
+		return nil
+	})
+}
@@ -0,0 +1,249 @@
+/*
+Package budget compares the time tracked on Toggl projects and tasks with their
+estimates, and notifies when budget thresholds are crossed.
+
+	tracker := budget.New(&session)
+	tracker.OnThreshold(func(e budget.Event) {
+		notify(fmt.Sprintf("%s reached %.0f%% of its budget", e.Status.Name, e.Threshold*100))
+	})
+	statuses, err := tracker.Check(wid)
+*/
+package budget
+
+import (
+	"sync"
+	"time"
+
+	"github.com/leucos/go-toggl"
+)
+
+// Default tracker settings
+var (
+	DefaultThresholds = []float64{0.75, 0.9, 1.0}
+	DefaultWindow     = 14 * 24 * time.Hour
+)
+
+// Status is the budget consumption of a project, or of a task when TaskID is
+// not zero.
+type Status struct {
+	WorkspaceID int
+	ProjectID   int
+	TaskID      int
+	Name        string
+	Estimated   time.Duration
+	// Tracked is the time tracked by all users.
+	Tracked time.Duration
+	// Ratio is the tracked time divided by the estimated time.
+	Ratio float64
+	// BurnRate is the time tracked per day by all users over the tracker
+	// window.
+	BurnRate time.Duration
+	// ProjectedCompletion is when the estimated time will be consumed at the
+	// current burn rate. It is zero if nothing was tracked during the window.
+	ProjectedCompletion time.Time
+}
+
+// Event is emitted when a status crosses a threshold.
+type Event struct {
+	Status    Status
+	Threshold float64
+}
+
+// Tracker computes budget statuses and emits an event the first time each
+// threshold is crossed.
+type Tracker struct {
+	// Thresholds are the ratios of the estimate that trigger events.
+	Thresholds []float64
+	// Window is the period over which burn rates are computed.
+	Window time.Duration
+
+	session  *toggl.Session
+	handlers []func(Event)
+	notified map[key]float64
+	mutex    sync.Mutex
+}
+
+type key struct {
+	project int
+	task    int
+}
+
+// New creates a tracker using the default thresholds and window.
+func New(session *toggl.Session) *Tracker {
+	return &Tracker{
+		Thresholds: append([]float64{}, DefaultThresholds...),
+		Window:     DefaultWindow,
+		session:    session,
+		notified:   make(map[key]float64),
+	}
+}
+
+// OnThreshold registers a function called for every threshold event.
+func (t *Tracker) OnThreshold(fn func(Event)) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.handlers = append(t.handlers, fn)
+}
+
+// Check computes the status of every estimated project and task in a
+// workspace, and emits events for the thresholds crossed since the last check.
+func (t *Tracker) Check(wid int) ([]Status, error) {
+	projects, err := t.session.GetProjects(wid)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, err := t.session.GetTasks(wid)
+	if err != nil {
+		return nil, err
+	}
+
+	recent, err := t.recentTime(wid)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []Status
+	for _, p := range projects {
+		if p.EstimatedHours != nil && *p.EstimatedHours > 0 {
+			statuses = append(statuses, t.projectStatus(p, recent))
+		}
+
+		for _, task := range tasks {
+			if task.Pid != p.ID || task.EstimatedSeconds == nil || *task.EstimatedSeconds <= 0 {
+				continue
+			}
+			statuses = append(statuses, t.taskStatus(p, task, recent))
+		}
+	}
+
+	for _, status := range statuses {
+		t.notify(status)
+	}
+
+	return statuses, nil
+}
+
+// ProjectStatus computes the budget status of a single project. No event is
+// emitted.
+func (t *Tracker) ProjectStatus(project toggl.Project) (Status, error) {
+	recent, err := t.recentTime(project.Wid)
+	if err != nil {
+		return Status{}, err
+	}
+
+	return t.projectStatus(project, recent), nil
+}
+
+// TaskStatus computes the budget status of a single task. No event is emitted.
+func (t *Tracker) TaskStatus(project toggl.Project, task toggl.Task) (Status, error) {
+	recent, err := t.recentTime(project.Wid)
+	if err != nil {
+		return Status{}, err
+	}
+
+	return t.taskStatus(project, task, recent), nil
+}
+
+func (t *Tracker) projectStatus(project toggl.Project, recent recentTime) Status {
+	var estimated time.Duration
+	if project.EstimatedHours != nil {
+		estimated = time.Duration(*project.EstimatedHours) * time.Hour
+	}
+
+	return t.status(Status{
+		WorkspaceID: project.Wid,
+		ProjectID:   project.ID,
+		Name:        project.Name,
+		Estimated:   estimated,
+		Tracked:     project.TrackedTime(),
+	}, recent.projects[project.ID])
+}
+
+func (t *Tracker) taskStatus(project toggl.Project, task toggl.Task, recent recentTime) Status {
+	var estimated time.Duration
+	if task.EstimatedSeconds != nil {
+		estimated = time.Duration(*task.EstimatedSeconds) * time.Second
+	}
+
+	return t.status(Status{
+		WorkspaceID: project.Wid,
+		ProjectID:   project.ID,
+		TaskID:      task.ID,
+		Name:        project.Name + " / " + task.Name,
+		Estimated:   estimated,
+		Tracked:     time.Duration(task.TrackedSeconds) * time.Second,
+	}, recent.tasks[task.ID])
+}
+
+// status fills in the computed fields of s, given the time tracked during the
+// window.
+func (t *Tracker) status(s Status, recent time.Duration) Status {
+	if s.Estimated > 0 {
+		s.Ratio = float64(s.Tracked) / float64(s.Estimated)
+	}
+
+	days := t.Window.Hours() / 24
+	if days <= 0 || recent <= 0 {
+		return s
+	}
+	s.BurnRate = time.Duration(float64(recent) / days)
+
+	remaining := s.Estimated - s.Tracked
+	if remaining < 0 {
+		remaining = 0
+	}
+	s.ProjectedCompletion = time.Now().Add(time.Duration(float64(remaining) / float64(s.BurnRate) * 24 * float64(time.Hour)))
+
+	return s
+}
+
+// notify emits an event for the highest threshold reached by status, if it
+// has not been emitted yet.
+func (t *Tracker) notify(status Status) {
+	t.mutex.Lock()
+	k := key{project: status.ProjectID, task: status.TaskID}
+	crossed := 0.0
+	for _, threshold := range t.Thresholds {
+		if status.Ratio >= threshold && threshold > crossed {
+			crossed = threshold
+		}
+	}
+	if crossed == 0 || crossed <= t.notified[k] {
+		t.mutex.Unlock()
+		return
+	}
+	t.notified[k] = crossed
+	handlers := append([]func(Event){}, t.handlers...)
+	t.mutex.Unlock()
+
+	for _, fn := range handlers {
+		fn(Event{Status: status, Threshold: crossed})
+	}
+}
+
+// recentTime is the time tracked by all the users of a workspace during the
+// tracker window, by project and by task.
+type recentTime struct {
+	projects map[int]time.Duration
+	tasks    map[int]time.Duration
+}
+
+func (t *Tracker) recentTime(wid int) (recentTime, error) {
+	recent := recentTime{projects: make(map[int]time.Duration), tasks: make(map[int]time.Duration)}
+
+	now := time.Now()
+	err := t.session.ForEachDetailedTimeEntry(wid, now.Add(-t.Window), now, func(e toggl.DetailedTimeEntry) error {
+		// reports durations are in milliseconds
+		d := time.Duration(e.Duration) * time.Millisecond
+		recent.projects[e.Pid] += d
+		recent.tasks[e.Tid] += d
+		return nil
+	})
+	if err != nil {
+		return recentTime{}, err
+	}
+
+	return recent, nil
+}
@@ -127,6 +127,56 @@ func (c *ResourcesCache) Set(rt resource.Type, wid int, id int, data any) {
 	c.caches[rt][wid][id] = data
 }
 
+// Update sets a resource in the cache only if the resources of this type
+// have already been cached for the workspace, so a partial list is never
+// mistaken for a complete one
+func (c *ResourcesCache) Update(rt resource.Type, wid int, id int, data any) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.caches[rt] == nil || c.caches[rt][wid] == nil {
+		return
+	}
+
+	c.caches[rt][wid][id] = data
+}
+
+// Delete removes a resource from the cache
+func (c *ResourcesCache) Delete(rt resource.Type, wid int, id int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.caches[rt] == nil || c.caches[rt][wid] == nil {
+		return
+	}
+
+	delete(c.caches[rt][wid], id)
+}
+
+// Init creates an empty cache for a given resource in the workspace, so that
+// the workspace is known to have no resources of that type
+func (c *ResourcesCache) Init(rt resource.Type, wid int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.caches[rt] == nil {
+		c.caches[rt] = make(map[int]map[int]any)
+	}
+
+	if c.caches[rt][wid] == nil {
+		c.caches[rt][wid] = make(map[int]any)
+	}
+}
+
+// Touch restarts the TTL of the cache for a given resource type, so freshly
+// loaded resources are not expired with the older ones
+func (c *ResourcesCache) Touch(rt resource.Type) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.timestamp[rt] = time.Now()
+}
+
 // GetTTL returns the cache TTL
 func (c *ResourcesCache) GetTTL() time.Duration {
 	return c.ttl
@@ -0,0 +1,153 @@
+package toggl
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ConflictError is returned by UpdateTimeEntryChecked when the time entry was
+// changed on the server in a way that cannot be merged with the local
+// changes.
+type ConflictError struct {
+	// Local is the entry as the caller wanted to save it, Server the entry as
+	// it is on the server.
+	Local  TimeEntry
+	Server TimeEntry
+	// Fields lists the fields changed on both sides to different values.
+	Fields []string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("time entry %d was changed on the server, conflicting fields: %s",
+		e.Server.ID, strings.Join(e.Fields, ", "))
+}
+
+// UpdateTimeEntryChecked saves timer, a modified copy of base, without
+// overwriting changes made on the server since base was fetched. The entry is
+// fetched again: if it was updated after base, the fields changed locally
+// and the fields changed on the server are merged. If a field was changed on
+// both sides to different values, nothing is saved and a *ConflictError is
+// returned.
+func (session *Session) UpdateTimeEntryChecked(base, timer TimeEntry) (TimeEntry, error) {
+	session.logger.Debug("updating timer with conflict check", "timer", timer)
+
+	server, err := session.GetTimeEntry(timer.ID)
+	if err != nil {
+		return TimeEntry{}, err
+	}
+
+	if base.At != nil && server.At != nil && !server.At.After(base.At.Time) {
+		return session.UpdateTimeEntry(timer)
+	}
+
+	merged, conflicts := mergeTimeEntries(base, timer, server)
+	if len(conflicts) > 0 {
+		return TimeEntry{}, &ConflictError{Local: timer, Server: server, Fields: conflicts}
+	}
+
+	session.logger.Debug("merged timer with server changes", "timer", merged)
+	return session.UpdateTimeEntry(merged)
+}
+
+// mergeTimeEntries applies to server the fields changed from base to local,
+// returning the names of the fields changed on both sides to different
+// values.
+func mergeTimeEntries(base, local, server TimeEntry) (TimeEntry, []string) {
+	merged := server
+	var conflicts []string
+
+	merge := func(name string, changed, theirs, same bool, apply func()) {
+		switch {
+		case !changed:
+		case theirs && !same:
+			conflicts = append(conflicts, name)
+		default:
+			apply()
+		}
+	}
+
+	merge("description",
+		local.Description != base.Description,
+		server.Description != base.Description,
+		local.Description == server.Description,
+		func() { merged.Description = local.Description })
+	merge("project",
+		!equalIntPtr(local.Pid, base.Pid),
+		!equalIntPtr(server.Pid, base.Pid),
+		equalIntPtr(local.Pid, server.Pid),
+		func() { merged.Pid = local.Pid })
+	merge("task",
+		!equalIntPtr(local.Tid, base.Tid),
+		!equalIntPtr(server.Tid, base.Tid),
+		equalIntPtr(local.Tid, server.Tid),
+		func() { merged.Tid = local.Tid })
+	merge("start",
+		!equalTimePtr(local.Start, base.Start),
+		!equalTimePtr(server.Start, base.Start),
+		equalTimePtr(local.Start, server.Start),
+		func() { merged.Start = local.Start })
+	merge("stop",
+		!equalStop(local, base),
+		!equalStop(server, base),
+		equalStop(local, server),
+		func() { merged.Stop, merged.Duration = local.Stop, local.Duration })
+	merge("billable",
+		local.Billable != base.Billable,
+		server.Billable != base.Billable,
+		local.Billable == server.Billable,
+		func() { merged.Billable = local.Billable })
+
+	// tags are merged as sets, so additions and removals never conflict
+	if !equalTags(local.Tags, base.Tags) {
+		tags := append([]string{}, server.Tags...)
+		for _, t := range local.Tags {
+			if !slices.Contains(base.Tags, t) && !slices.Contains(tags, t) {
+				tags = append(tags, t)
+			}
+		}
+		for _, t := range base.Tags {
+			if !slices.Contains(local.Tags, t) {
+				tags = slices.DeleteFunc(tags, func(s string) bool { return s == t })
+			}
+		}
+		merged.Tags = tags
+	}
+
+	// a start change on one side and a stop change on the other must keep
+	// the duration consistent
+	if !merged.IsRunning() && merged.Start != nil && merged.Stop != nil {
+		merged.Duration = int64(merged.Stop.Sub(*merged.Start) / time.Second)
+	}
+
+	return merged, conflicts
+}
+
+func equalIntPtr(a, b *int) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
+
+func equalTimePtr(a, b *time.Time) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && a.Equal(*b))
+}
+
+// equalStop compares the stops of two entries. The duration of a stopped
+// entry follows from its start and stop, so it is only compared for running
+// entries.
+func equalStop(a, b TimeEntry) bool {
+	if a.Stop == nil && b.Stop == nil {
+		return a.Duration == b.Duration
+	}
+	return equalTimePtr(a.Stop, b.Stop)
+}
+
+func equalTags(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(a, b)
+}
@@ -0,0 +1,141 @@
+package toggl
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestMergeTimeEntries(t *testing.T) {
+	at := func(hour int) *time.Time {
+		t := time.Date(2026, 10, 17, hour, 0, 0, 0, time.UTC)
+		return &t
+	}
+	id := func(i int) *int { return &i }
+
+	base := TimeEntry{
+		ID:          1,
+		Description: "Meeting",
+		Pid:         id(10),
+		Start:       at(9),
+		Stop:        at(10),
+		Duration:    3600,
+		Tags:        []string{"a", "b"},
+	}
+
+	tests := []struct {
+		name      string
+		local     func(*TimeEntry)
+		server    func(*TimeEntry)
+		want      func(*TimeEntry)
+		conflicts []string
+	}{
+		{
+			name:   "local change only",
+			local:  func(e *TimeEntry) { e.Description = "Review" },
+			server: func(e *TimeEntry) {},
+			want:   func(e *TimeEntry) { e.Description = "Review" },
+		},
+		{
+			name:   "server change only",
+			local:  func(e *TimeEntry) {},
+			server: func(e *TimeEntry) { e.Pid = id(11) },
+			want:   func(e *TimeEntry) { e.Pid = id(11) },
+		},
+		{
+			name:   "different fields",
+			local:  func(e *TimeEntry) { e.Description = "Review" },
+			server: func(e *TimeEntry) { e.Billable = true },
+			want:   func(e *TimeEntry) { e.Description = "Review"; e.Billable = true },
+		},
+		{
+			name:   "same change on both sides",
+			local:  func(e *TimeEntry) { e.Pid = id(12) },
+			server: func(e *TimeEntry) { e.Pid = id(12) },
+			want:   func(e *TimeEntry) { e.Pid = id(12) },
+		},
+		{
+			name:      "conflicting descriptions",
+			local:     func(e *TimeEntry) { e.Description = "Review" },
+			server:    func(e *TimeEntry) { e.Description = "Standup" },
+			want:      func(e *TimeEntry) { e.Description = "Standup" },
+			conflicts: []string{"description"},
+		},
+		{
+			name:      "project removed locally, changed on the server",
+			local:     func(e *TimeEntry) { e.Pid = nil },
+			server:    func(e *TimeEntry) { e.Pid = id(11) },
+			want:      func(e *TimeEntry) { e.Pid = id(11) },
+			conflicts: []string{"project"},
+		},
+		{
+			name:   "start and stop changed on different sides",
+			local:  func(e *TimeEntry) { e.Start = at(8); e.Duration = 7200 },
+			server: func(e *TimeEntry) { e.Stop = at(11); e.Duration = 7200 },
+			want:   func(e *TimeEntry) { e.Start = at(8); e.Stop = at(11); e.Duration = 3 * 3600 },
+		},
+		{
+			name:      "conflicting stops",
+			local:     func(e *TimeEntry) { e.Stop = at(11); e.Duration = 7200 },
+			server:    func(e *TimeEntry) { e.Stop = at(12); e.Duration = 3 * 3600 },
+			want:      func(e *TimeEntry) { e.Stop = at(12); e.Duration = 3 * 3600 },
+			conflicts: []string{"stop"},
+		},
+		{
+			name:   "tags merged as sets",
+			local:  func(e *TimeEntry) { e.Tags = []string{"a", "c"} },
+			server: func(e *TimeEntry) { e.Tags = []string{"a", "b", "d"} },
+			want:   func(e *TimeEntry) { e.Tags = []string{"a", "d", "c"} },
+		},
+		{
+			name:   "tags unchanged locally",
+			local:  func(e *TimeEntry) { e.Description = "Review" },
+			server: func(e *TimeEntry) { e.Tags = nil },
+			want:   func(e *TimeEntry) { e.Description = "Review"; e.Tags = nil },
+		},
+		{
+			name:      "several conflicts",
+			local:     func(e *TimeEntry) { e.Description = "Review"; e.Billable = true; e.Tid = id(1) },
+			server:    func(e *TimeEntry) { e.Description = "Standup"; e.Tid = id(2) },
+			want:      func(e *TimeEntry) { e.Description = "Standup"; e.Billable = true; e.Tid = id(2) },
+			conflicts: []string{"description", "task"},
+		},
+	}
+
+	for _, tt := range tests {
+		local, server, want := base.Copy(), base.Copy(), base.Copy()
+		tt.local(&local)
+		tt.server(&server)
+		tt.want(&want)
+
+		merged, conflicts := mergeTimeEntries(base, local, server)
+		if !slices.Equal(conflicts, tt.conflicts) {
+			t.Errorf("%s: conflicts = %q, want %q", tt.name, conflicts, tt.conflicts)
+		}
+		if merged.Description != want.Description || !equalIntPtr(merged.Pid, want.Pid) ||
+			!equalIntPtr(merged.Tid, want.Tid) || merged.Billable != want.Billable ||
+			!equalTimePtr(merged.Start, want.Start) || !equalTimePtr(merged.Stop, want.Stop) ||
+			merged.Duration != want.Duration || !slices.Equal(merged.Tags, want.Tags) {
+			t.Errorf("%s: merged = %+v, want %+v", tt.name, merged, want)
+		}
+	}
+}
+
+func TestEqualTags(t *testing.T) {
+	tests := []struct {
+		a, b []string
+		want bool
+	}{
+		{nil, nil, true},
+		{nil, []string{}, true},
+		{[]string{"a", "b"}, []string{"b", "a"}, true},
+		{[]string{"a"}, []string{"a", "b"}, false},
+		{[]string{"a", "a"}, []string{"a", "b"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := equalTags(tt.a, tt.b); got != tt.want {
+			t.Errorf("equalTags(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -2,6 +2,13 @@ module github.com/leucos/go-toggl
 
 go 1.23
 
-require github.com/hashicorp/go-retryablehttp v0.7.7
+require (
+	github.com/hashicorp/go-retryablehttp v0.7.7
+	golang.org/x/term v0.25.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
-require github.com/hashicorp/go-cleanhttp v0.5.2 // indirect
+require (
+	github.com/hashicorp/go-cleanhttp v0.5.2 // indirect
+	golang.org/x/sys v0.26.0 // indirect
+)
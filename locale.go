@@ -0,0 +1,150 @@
+package toggl
+
+import (
+	"sync"
+	"time"
+)
+
+// locale is the time zone and first day of the week of the user, which
+// define days and weeks. Unless set explicitly, they are taken from the
+// account the first time they are needed.
+type locale struct {
+	mutex     sync.Mutex
+	location  *time.Location
+	weekStart time.Weekday
+	known     bool
+	explicit  bool
+}
+
+func newLocale() *locale {
+	return &locale{location: time.Local, weekStart: time.Monday}
+}
+
+func (session *Session) getLocale() *locale {
+	if session.locale == nil {
+		session.locale = newLocale()
+	}
+	l := session.locale
+
+	l.mutex.Lock()
+	known := l.known
+	l.mutex.Unlock()
+
+	if !known {
+		session.loadLocale()
+	}
+	return l
+}
+
+// loadLocale fetches the locale of the account. On failure, the local time
+// zone is used until the account is fetched again.
+func (session *Session) loadLocale() {
+	var account Account
+	data, err := session.get(TogglAPI, "/me", nil)
+	if err == nil {
+		err = decodeAccount(data, &account)
+	}
+	if err != nil {
+		session.logger.Debug("using local time zone", "error", err)
+		session.locale.mutex.Lock()
+		session.locale.known = true
+		session.locale.mutex.Unlock()
+		return
+	}
+
+	session.setAccountLocale(account)
+}
+
+// setAccountLocale records the locale of the account, unless one was set
+// explicitly.
+func (session *Session) setAccountLocale(account Account) {
+	if session.locale == nil {
+		session.locale = newLocale()
+	}
+	l := session.locale
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.explicit {
+		return
+	}
+
+	l.location = time.Local
+	if account.Timezone != "" {
+		loc, err := time.LoadLocation(account.Timezone)
+		if err != nil {
+			session.logger.Debug("unknown account time zone", "timezone", account.Timezone, "error", err)
+		} else {
+			l.location = loc
+		}
+	}
+	l.weekStart = time.Weekday(account.BeginningOfWeek % 7)
+	l.known = true
+}
+
+// SetLocale sets the time zone and first day of the week used for days and
+// weeks, instead of the account's ones.
+func (session *Session) SetLocale(loc *time.Location, weekStart time.Weekday) {
+	if session.locale == nil {
+		session.locale = newLocale()
+	}
+	l := session.locale
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.location = loc
+	l.weekStart = weekStart
+	l.known = true
+	l.explicit = true
+}
+
+// Location returns the time zone of the user, that of the account unless
+// set with SetLocale. The local time zone is returned when the account
+// cannot be fetched.
+func (session *Session) Location() *time.Location {
+	l := session.getLocale()
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.location
+}
+
+// WeekStart returns the first day of the week of the user, that of the
+// account unless set with SetLocale.
+func (session *Session) WeekStart() time.Weekday {
+	l := session.getLocale()
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.weekStart
+}
+
+// Now returns the current time in the user's time zone.
+func (session *Session) Now() time.Time {
+	return time.Now().In(session.Location())
+}
+
+// StartOfDay returns the start of the day of t in the user's time zone.
+func (session *Session) StartOfDay(t time.Time) time.Time {
+	t = t.In(session.Location())
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// StartOfWeek returns the start of the week of t in the user's time zone,
+// following the user's first day of the week.
+func (session *Session) StartOfWeek(t time.Time) time.Time {
+	day := session.StartOfDay(t)
+	offset := (int(day.Weekday()) - int(session.WeekStart()) + 7) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+// SameDay reports whether a and b are on the same day in the user's time
+// zone.
+func (session *Session) SameDay(a, b time.Time) bool {
+	return session.StartOfDay(a).Equal(session.StartOfDay(b))
+}
+
+// reportDate formats t as a day of the reports API, in the user's time zone.
+func (session *Session) reportDate(t time.Time) string {
+	return t.In(session.Location()).Format("2006-01-02")
+}
@@ -0,0 +1,530 @@
+/*
+Package migrate copies or moves clients, projects, tags and time entries from a
+Toggl workspace to another.
+
+Migrations remap the IDs of the copied resources and record the mapping in a
+State, which can be saved between runs: rerunning a migration with the same
+state only copies what was not copied yet. Resources that already exist in the
+target workspace with the same name are reused instead of duplicated. Time
+entries keep their users, who must be members of the target workspace.
+
+	state, _ := migrate.LoadState("migration.json")
+	plan, err := migrate.Run(&session, migrate.Options{From: 1, To: 2, State: state, DryRun: true})
+	for _, a := range plan.Actions {
+		fmt.Println(a)
+	}
+*/
+package migrate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/leucos/go-toggl"
+)
+
+// Filter selects the resources to migrate. Zero values select everything.
+type Filter struct {
+	// Clients selects the clients, and their projects, by ID.
+	Clients []int
+	// Projects selects projects by ID. Their clients are migrated too.
+	Projects []int
+	// Tags selects tags by name, in addition to the tags used by the
+	// migrated time entries.
+	Tags []string
+	// Since and Until restrict the migrated time entries. Since defaults to
+	// a year ago, or to the whole history for moves, and Until to now.
+	Since time.Time
+	Until time.Time
+	// Period restricts the migrated time entries instead of Since and
+	// Until, unless it is zero.
+	Period toggl.Period
+	// SkipTimeEntries only migrates clients, projects and tags.
+	SkipTimeEntries bool
+}
+
+// Options describes a migration.
+type Options struct {
+	// From and To are the source and target workspaces.
+	From int
+	To   int
+	// Filter selects the resources to migrate.
+	Filter Filter
+	// Move deletes the migrated resources from the source workspace once
+	// everything has been copied. It needs the whole history: the filter
+	// cannot restrict time entries by date, and the migration fails before
+	// copying anything if an unmigrated project or time entry uses one of
+	// the clients or tags to delete.
+	Move bool
+	// DryRun only computes the plan.
+	DryRun bool
+	// State holds the IDs mapping of previous runs. It is updated in place,
+	// except in dry-run mode.
+	State *State
+}
+
+// State maps the IDs of source resources to the IDs of their copies.
+type State struct {
+	Clients     map[int]int `json:"clients"`
+	Projects    map[int]int `json:"projects"`
+	Tags        map[int]int `json:"tags"`
+	TimeEntries map[int]int `json:"time_entries"`
+	// Deleted holds the "kind:id" keys of the deleted source resources.
+	Deleted map[string]bool `json:"deleted"`
+}
+
+// NewState returns an empty state.
+func NewState() *State {
+	s := &State{}
+	s.init()
+	return s
+}
+
+// LoadState reads a state saved by Save. A missing file yields an empty state.
+func LoadState(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return NewState(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s := &State{}
+	err = json.Unmarshal(data, s)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding migration state: %v", err)
+	}
+	s.init()
+
+	return s, nil
+}
+
+// Save writes the state to a file.
+func (s *State) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0o600)
+}
+
+func (s *State) init() {
+	if s.Clients == nil {
+		s.Clients = make(map[int]int)
+	}
+	if s.Projects == nil {
+		s.Projects = make(map[int]int)
+	}
+	if s.Tags == nil {
+		s.Tags = make(map[int]int)
+	}
+	if s.TimeEntries == nil {
+		s.TimeEntries = make(map[int]int)
+	}
+	if s.Deleted == nil {
+		s.Deleted = make(map[string]bool)
+	}
+}
+
+// clone returns a copy of the state, for dry runs to plan on.
+func (s *State) clone() *State {
+	c := &State{
+		Clients:     maps.Clone(s.Clients),
+		Projects:    maps.Clone(s.Projects),
+		Tags:        maps.Clone(s.Tags),
+		TimeEntries: maps.Clone(s.TimeEntries),
+		Deleted:     maps.Clone(s.Deleted),
+	}
+	c.init()
+	return c
+}
+
+// Operations of a migration
+const (
+	Create = "create"
+	Reuse  = "reuse"
+	Skip   = "skip"
+	Delete = "delete"
+)
+
+// Action is a step of a migration.
+type Action struct {
+	// Kind is one of "client", "project", "tag" or "time_entry".
+	Kind string
+	// Op is one of Create, Reuse, Skip or Delete.
+	Op       string
+	SourceID int
+	// TargetID is the ID of the copy. It is zero for deletions and for
+	// creations in dry-run mode.
+	TargetID int
+	Name     string
+}
+
+func (a Action) String() string {
+	return fmt.Sprintf("%s %s %d %q -> %d", a.Op, a.Kind, a.SourceID, a.Name, a.TargetID)
+}
+
+// Plan lists the actions of a migration, in order.
+type Plan struct {
+	DryRun  bool
+	Actions []Action
+}
+
+// Run migrates the selected resources. It stops at the first error, returning
+// the actions done so far; since the state is updated as the migration goes,
+// running it again with the same state resumes where it stopped.
+func Run(session *toggl.Session, opts Options) (Plan, error) {
+	if opts.State == nil {
+		opts.State = NewState()
+	}
+	opts.State.init()
+
+	state := opts.State
+	if opts.DryRun {
+		state = state.clone()
+	}
+
+	m := &migration{session: session, opts: opts, state: state, plan: Plan{DryRun: opts.DryRun}}
+
+	f := opts.Filter
+	if opts.Move && (!f.Since.IsZero() || !f.Until.IsZero() || !f.Period.IsZero() || f.SkipTimeEntries) {
+		return m.plan, errors.New("a move needs the whole history, without time entries filters")
+	}
+
+	if err := m.selectResources(); err != nil {
+		return m.plan, err
+	}
+	if opts.Move {
+		if err := m.checkUnused(); err != nil {
+			return m.plan, err
+		}
+	}
+
+	steps := []func() error{m.migrateClients, m.migrateProjects, m.migrateTags, m.migrateTimeEntries}
+	if opts.Move {
+		steps = append(steps, m.deleteSources)
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			return m.plan, err
+		}
+	}
+
+	return m.plan, nil
+}
+
+type migration struct {
+	session *toggl.Session
+	opts    Options
+	state   *State
+	plan    Plan
+
+	clients  []toggl.Client
+	projects []toggl.Project
+	tags     []toggl.Tag
+	entries  []toggl.DetailedTimeEntry
+
+	// keptClients and keptTags are used by the source projects and time
+	// entries that are not migrated.
+	keptClients map[int]bool
+	keptTags    map[string]bool
+}
+
+// selectResources lists the source resources matching the filter.
+func (m *migration) selectResources() error {
+	f := m.opts.Filter
+
+	clients, err := m.session.GetClients(m.opts.From)
+	if err != nil {
+		return fmt.Errorf("error listing clients: %v", err)
+	}
+	projects, err := m.session.GetProjects(m.opts.From, toggl.AllProjects)
+	if err != nil {
+		return fmt.Errorf("error listing projects: %v", err)
+	}
+	tags, err := m.session.GetTags(m.opts.From)
+	if err != nil {
+		return fmt.Errorf("error listing tags: %v", err)
+	}
+
+	filtered := len(f.Clients) > 0 || len(f.Projects) > 0
+	clientIDs := map[int]bool{}
+	projectIDs := map[int]bool{}
+	m.keptClients = map[int]bool{}
+	m.keptTags = map[string]bool{}
+	for _, p := range projects {
+		if !filtered || slices.Contains(f.Projects, p.ID) || (p.Cid != nil && slices.Contains(f.Clients, *p.Cid)) {
+			m.projects = append(m.projects, p)
+			projectIDs[p.ID] = true
+			if p.Cid != nil {
+				clientIDs[*p.Cid] = true
+			}
+		} else if p.Cid != nil {
+			m.keptClients[*p.Cid] = true
+		}
+	}
+	for _, c := range clients {
+		if !filtered || clientIDs[c.ID] || slices.Contains(f.Clients, c.ID) {
+			m.clients = append(m.clients, c)
+		}
+	}
+
+	tagNames := map[string]bool{}
+	for _, name := range f.Tags {
+		tagNames[name] = true
+	}
+
+	if !f.SkipTimeEntries {
+		since, until := f.Since, f.Until
+		if !f.Period.IsZero() {
+			since, until = f.Period.Start, f.Period.Last()
+		}
+		if until.IsZero() {
+			until = time.Now()
+		}
+		if since.IsZero() {
+			since = until.AddDate(-1, 0, 0)
+		}
+		if m.opts.Move {
+			since = toggl.Epoch
+		}
+
+		err = m.session.ForEachDetailedTimeEntry(m.opts.From, since, until, func(e toggl.DetailedTimeEntry) error {
+			if filtered && !projectIDs[e.Pid] {
+				for _, t := range e.Tags {
+					m.keptTags[t] = true
+				}
+				return nil
+			}
+			m.entries = append(m.entries, e)
+			for _, t := range e.Tags {
+				tagNames[t] = true
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("error listing time entries: %v", err)
+		}
+	}
+
+	for _, t := range tags {
+		if (!filtered && len(f.Tags) == 0) || tagNames[t.Name] {
+			m.tags = append(m.tags, t)
+		}
+	}
+
+	return nil
+}
+
+// checkUnused fails if a client or tag to delete on a move is still used by
+// the source resources that are not migrated.
+func (m *migration) checkUnused() error {
+	for _, c := range m.clients {
+		if m.keptClients[c.ID] {
+			return fmt.Errorf("cannot move client %q, unmigrated projects use it", c.Name)
+		}
+	}
+	for _, t := range m.tags {
+		if m.keptTags[t.Name] {
+			return fmt.Errorf("cannot move tag %q, unmigrated time entries use it", t.Name)
+		}
+	}
+	return nil
+}
+
+// record adds an action to the plan.
+func (m *migration) record(kind, op string, sourceID, targetID int, name string) {
+	m.plan.Actions = append(m.plan.Actions, Action{Kind: kind, Op: op, SourceID: sourceID, TargetID: targetID, Name: name})
+}
+
+func (m *migration) migrateClients() error {
+	existing, err := m.session.GetClients(m.opts.To)
+	if err != nil {
+		return fmt.Errorf("error listing target clients: %v", err)
+	}
+
+	for _, c := range m.clients {
+		if id, ok := m.state.Clients[c.ID]; ok {
+			m.record("client", Skip, c.ID, id, c.Name)
+			continue
+		}
+		if i := slices.IndexFunc(existing, func(t toggl.Client) bool { return t.Name == c.Name }); i >= 0 {
+			m.state.Clients[c.ID] = existing[i].ID
+			m.record("client", Reuse, c.ID, existing[i].ID, c.Name)
+			continue
+		}
+		if m.opts.DryRun {
+			m.record("client", Create, c.ID, 0, c.Name)
+			continue
+		}
+
+		created, err := m.session.CreateClient(c.Name, m.opts.To)
+		if err != nil {
+			return fmt.Errorf("error creating client %q: %v", c.Name, err)
+		}
+		m.state.Clients[c.ID] = created.ID
+		m.record("client", Create, c.ID, created.ID, c.Name)
+	}
+
+	return nil
+}
+
+func (m *migration) migrateProjects() error {
+	existing, err := m.session.GetProjects(m.opts.To, toggl.AllProjects)
+	if err != nil {
+		return fmt.Errorf("error listing target projects: %v", err)
+	}
+
+	for _, p := range m.projects {
+		if id, ok := m.state.Projects[p.ID]; ok {
+			m.record("project", Skip, p.ID, id, p.Name)
+			continue
+		}
+
+		var cid *int
+		if p.Cid != nil {
+			if id, ok := m.state.Clients[*p.Cid]; ok {
+				cid = &id
+			}
+		}
+
+		if i := slices.IndexFunc(existing, func(t toggl.Project) bool {
+			return t.Name == p.Name && ((t.Cid == nil && cid == nil) || (t.Cid != nil && cid != nil && *t.Cid == *cid))
+		}); i >= 0 {
+			m.state.Projects[p.ID] = existing[i].ID
+			m.record("project", Reuse, p.ID, existing[i].ID, p.Name)
+			continue
+		}
+		if m.opts.DryRun {
+			m.record("project", Create, p.ID, 0, p.Name)
+			continue
+		}
+
+		projectOpts := p.CopyOptions()
+		projectOpts.ClientID = cid
+
+		created, err := m.session.CreateProjectWithOptions(m.opts.To, projectOpts)
+		if err != nil {
+			return fmt.Errorf("error creating project %q: %v", p.Name, err)
+		}
+		m.state.Projects[p.ID] = created.ID
+		m.record("project", Create, p.ID, created.ID, p.Name)
+	}
+
+	return nil
+}
+
+func (m *migration) migrateTags() error {
+	for _, t := range m.tags {
+		if id, ok := m.state.Tags[t.ID]; ok {
+			m.record("tag", Skip, t.ID, id, t.Name)
+			continue
+		}
+
+		existing, ok, err := m.session.FindTagByName(m.opts.To, t.Name)
+		if err != nil {
+			return fmt.Errorf("error listing target tags: %v", err)
+		}
+		if ok {
+			m.state.Tags[t.ID] = existing.ID
+			m.record("tag", Reuse, t.ID, existing.ID, t.Name)
+			continue
+		}
+		if m.opts.DryRun {
+			m.record("tag", Create, t.ID, 0, t.Name)
+			continue
+		}
+
+		created, err := m.session.CreateTag(t.Name, m.opts.To)
+		if err != nil {
+			return fmt.Errorf("error creating tag %q: %v", t.Name, err)
+		}
+		m.state.Tags[t.ID] = created.ID
+		m.record("tag", Create, t.ID, created.ID, t.Name)
+	}
+
+	return nil
+}
+
+func (m *migration) migrateTimeEntries() error {
+	for _, e := range m.entries {
+		if id, ok := m.state.TimeEntries[e.ID]; ok {
+			m.record("time_entry", Skip, e.ID, id, e.Description)
+			continue
+		}
+		if m.opts.DryRun {
+			m.record("time_entry", Create, e.ID, 0, e.Description)
+			continue
+		}
+
+		// the entry keeps its user, tasks are not migrated
+		entry := e.TimeEntry(m.opts.To)
+		entry.Pid, entry.Tid = nil, nil
+		if id, ok := m.state.Projects[e.Pid]; ok {
+			entry.Pid = &id
+		}
+
+		created, err := m.session.CreateTimeEntry(entry)
+		if err != nil {
+			return fmt.Errorf("error creating time entry %d: %v", e.ID, err)
+		}
+		m.state.TimeEntries[e.ID] = created.ID
+		m.record("time_entry", Create, e.ID, created.ID, e.Description)
+	}
+
+	return nil
+}
+
+// deleteSources deletes the migrated resources from the source workspace,
+// time entries first so that projects, clients and tags are unused when they
+// are deleted.
+func (m *migration) deleteSources() error {
+	del := func(kind string, id int, name string, fn func() ([]byte, error)) error {
+		key := fmt.Sprintf("%s:%d", kind, id)
+		if m.state.Deleted[key] {
+			return nil
+		}
+		m.record(kind, Delete, id, 0, name)
+		if m.opts.DryRun {
+			return nil
+		}
+		if _, err := fn(); err != nil {
+			return fmt.Errorf("error deleting %s %d: %v", kind, id, err)
+		}
+		m.state.Deleted[key] = true
+		return nil
+	}
+
+	for _, e := range m.entries {
+		err := del("time_entry", e.ID, e.Description, func() ([]byte, error) {
+			return m.session.DeleteTimeEntry(toggl.TimeEntry{Wid: m.opts.From, ID: e.ID})
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for _, p := range m.projects {
+		if err := del("project", p.ID, p.Name, func() ([]byte, error) { return m.session.DeleteProject(p) }); err != nil {
+			return err
+		}
+	}
+	for _, c := range m.clients {
+		if err := del("client", c.ID, c.Name, func() ([]byte, error) { return m.session.DeleteClient(c) }); err != nil {
+			return err
+		}
+	}
+	for _, t := range m.tags {
+		if err := del("tag", t.ID, t.Name, func() ([]byte, error) { return m.session.DeleteTag(t) }); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
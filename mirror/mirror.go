@@ -0,0 +1,359 @@
+/*
+Package mirror keeps a local SQL copy of the user's time entries, and of the
+projects, clients and tags of Toggl workspaces, so that they can be queried
+offline. The time entries of the other users of a workspace are not mirrored,
+since only the user's own changes can be fetched incrementally.
+
+The mirror works on a *sql.DB opened by the caller with a SQLite driver of
+their choice, e.g.:
+
+	db, _ := sql.Open("sqlite", "toggl.db")
+	m, _ := mirror.New(&session, db)
+	stats, err := m.Sync(ctx, wid)
+
+The first sync of a workspace loads the user's history from the reports API,
+going back Mirror.History. Later syncs only fetch the time entries and
+projects changed since the previous sync, and drop the ones deleted on the
+server. Clients and tags, which are small lists, are fully refreshed on every
+sync.
+
+Timestamps are stored as RFC 3339 UTC strings, tags as JSON arrays, and
+durations in seconds.
+*/
+package mirror
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/leucos/go-toggl"
+	"github.com/leucos/go-toggl/resource"
+)
+
+// schema creates the mirror tables.
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS time_entries (
+		id INTEGER PRIMARY KEY,
+		workspace_id INTEGER NOT NULL,
+		project_id INTEGER,
+		task_id INTEGER,
+		description TEXT NOT NULL DEFAULT '',
+		start TEXT,
+		stop TEXT,
+		duration INTEGER NOT NULL DEFAULT 0,
+		billable INTEGER NOT NULL DEFAULT 0,
+		tags TEXT NOT NULL DEFAULT '[]',
+		updated_at TEXT
+	)`,
+	`CREATE INDEX IF NOT EXISTS time_entries_start ON time_entries (workspace_id, start)`,
+	`CREATE TABLE IF NOT EXISTS projects (
+		id INTEGER PRIMARY KEY,
+		workspace_id INTEGER NOT NULL,
+		client_id INTEGER,
+		name TEXT NOT NULL,
+		active INTEGER NOT NULL DEFAULT 1,
+		billable INTEGER NOT NULL DEFAULT 0,
+		color TEXT NOT NULL DEFAULT '',
+		estimated_hours INTEGER
+	)`,
+	`CREATE TABLE IF NOT EXISTS clients (
+		id INTEGER PRIMARY KEY,
+		workspace_id INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		archived INTEGER NOT NULL DEFAULT 0
+	)`,
+	`CREATE TABLE IF NOT EXISTS tags (
+		id INTEGER PRIMARY KEY,
+		workspace_id INTEGER NOT NULL,
+		name TEXT NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS sync_state (
+		workspace_id INTEGER PRIMARY KEY,
+		last_sync TEXT NOT NULL
+	)`,
+}
+
+// DefaultHistory is how far back the first sync of a workspace goes.
+var DefaultHistory = 365 * 24 * time.Hour
+
+// Mirror syncs Toggl workspaces into a SQL database.
+type Mirror struct {
+	// History is how far back the first sync of a workspace goes. Zero
+	// loads the whole history.
+	History time.Duration
+
+	session *toggl.Session
+	db      *sql.DB
+}
+
+// Stats counts the rows changed by a sync.
+type Stats struct {
+	// Full is true if the history was loaded, as far back as
+	// Mirror.History.
+	Full        bool
+	TimeEntries int
+	Projects    int
+	Clients     int
+	Tags        int
+	Deleted     int
+}
+
+// New creates the mirror tables in db if needed.
+func New(session *toggl.Session, db *sql.DB) (*Mirror, error) {
+	for _, stmt := range schema {
+		if _, err := db.Exec(stmt); err != nil {
+			return nil, fmt.Errorf("error creating mirror schema: %v", err)
+		}
+	}
+
+	return &Mirror{History: DefaultHistory, session: session, db: db}, nil
+}
+
+// LastSync returns when a workspace was last synced, or the zero time if it
+// never was.
+func (m *Mirror) LastSync(ctx context.Context, wid int) (time.Time, error) {
+	var last string
+	err := m.db.QueryRowContext(ctx, `SELECT last_sync FROM sync_state WHERE workspace_id = ?`, wid).Scan(&last)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Parse(time.RFC3339, last)
+}
+
+// Sync brings the mirror of a workspace up to date.
+func (m *Mirror) Sync(ctx context.Context, wid int) (Stats, error) {
+	var stats Stats
+
+	last, err := m.LastSync(ctx, wid)
+	if err != nil {
+		return stats, fmt.Errorf("error reading sync state: %v", err)
+	}
+
+	// changes made while syncing will be fetched again by the next sync
+	started := time.Now().UTC().Truncate(time.Second)
+
+	m.session.InvalidateCache(resource.Clients, resource.Tags)
+	clients, err := m.session.GetClients(wid)
+	if err != nil {
+		return stats, fmt.Errorf("error fetching clients: %v", err)
+	}
+	tags, err := m.session.GetTags(wid)
+	if err != nil {
+		return stats, fmt.Errorf("error fetching tags: %v", err)
+	}
+
+	var projects []toggl.Project
+	if last.IsZero() {
+		projects, err = m.session.GetProjects(wid, toggl.AllProjects)
+	} else {
+		projects, err = m.session.GetProjectsSince(wid, last)
+	}
+	if err != nil {
+		return stats, fmt.Errorf("error fetching projects: %v", err)
+	}
+
+	var entries []toggl.TimeEntry
+	if last.IsZero() {
+		stats.Full = true
+		entries, err = m.history(wid, started)
+	} else {
+		entries, err = m.session.GetTimeEntriesSince(last)
+	}
+	if err != nil {
+		return stats, fmt.Errorf("error fetching time entries: %v", err)
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return stats, err
+	}
+	defer tx.Rollback()
+
+	if err := syncClients(ctx, tx, wid, clients, &stats); err != nil {
+		return stats, err
+	}
+	if err := syncTags(ctx, tx, wid, tags, &stats); err != nil {
+		return stats, err
+	}
+	if err := syncProjects(ctx, tx, projects, &stats); err != nil {
+		return stats, err
+	}
+	if err := syncTimeEntries(ctx, tx, wid, entries, &stats); err != nil {
+		return stats, err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO sync_state (workspace_id, last_sync) VALUES (?, ?)
+		ON CONFLICT (workspace_id) DO UPDATE SET last_sync = excluded.last_sync`,
+		wid, started.Format(time.RFC3339))
+	if err != nil {
+		return stats, fmt.Errorf("error saving sync state: %v", err)
+	}
+
+	return stats, tx.Commit()
+}
+
+// history loads the user's time entries of a workspace from the reports API,
+// the same entries as later syncs fetch.
+func (m *Mirror) history(wid int, until time.Time) ([]toggl.TimeEntry, error) {
+	since := toggl.Epoch
+	if m.History > 0 {
+		since = until.Add(-m.History)
+	}
+
+	account, err := m.session.GetAccount()
+	if err != nil {
+		return nil, fmt.Errorf("error fetching account: %v", err)
+	}
+
+	var entries []toggl.TimeEntry
+	err = m.session.ForEachUserDetailedTimeEntry(wid, account.ID, since, until, func(e toggl.DetailedTimeEntry) error {
+		entries = append(entries, e.TimeEntry(wid))
+		return nil
+	})
+
+	return entries, err
+}
+
+func syncClients(ctx context.Context, tx *sql.Tx, wid int, clients []toggl.Client, stats *Stats) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM clients WHERE workspace_id = ?`, wid); err != nil {
+		return fmt.Errorf("error syncing clients: %v", err)
+	}
+
+	for _, c := range clients {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO clients (id, workspace_id, name, archived) VALUES (?, ?, ?, ?)`,
+			c.ID, wid, c.Name, c.Archived)
+		if err != nil {
+			return fmt.Errorf("error syncing client %d: %v", c.ID, err)
+		}
+		stats.Clients++
+	}
+
+	return nil
+}
+
+func syncTags(ctx context.Context, tx *sql.Tx, wid int, tags []toggl.Tag, stats *Stats) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM tags WHERE workspace_id = ?`, wid); err != nil {
+		return fmt.Errorf("error syncing tags: %v", err)
+	}
+
+	for _, t := range tags {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO tags (id, workspace_id, name) VALUES (?, ?, ?)`,
+			t.ID, wid, t.Name)
+		if err != nil {
+			return fmt.Errorf("error syncing tag %d: %v", t.ID, err)
+		}
+		stats.Tags++
+	}
+
+	return nil
+}
+
+func syncProjects(ctx context.Context, tx *sql.Tx, projects []toggl.Project, stats *Stats) error {
+	for _, p := range projects {
+		if p.ServerDeletedAt != nil {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM projects WHERE id = ?`, p.ID); err != nil {
+				return fmt.Errorf("error deleting project %d: %v", p.ID, err)
+			}
+			stats.Deleted++
+			continue
+		}
+
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO projects (id, workspace_id, client_id, name, active, billable, color, estimated_hours)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (id) DO UPDATE SET
+				workspace_id = excluded.workspace_id,
+				client_id = excluded.client_id,
+				name = excluded.name,
+				active = excluded.active,
+				billable = excluded.billable,
+				color = excluded.color,
+				estimated_hours = excluded.estimated_hours`,
+			p.ID, p.Wid, nullInt(p.Cid), p.Name, p.Active, p.Billable != nil && *p.Billable, p.Color, nullInt(p.EstimatedHours))
+		if err != nil {
+			return fmt.Errorf("error syncing project %d: %v", p.ID, err)
+		}
+		stats.Projects++
+	}
+
+	return nil
+}
+
+func syncTimeEntries(ctx context.Context, tx *sql.Tx, wid int, entries []toggl.TimeEntry, stats *Stats) error {
+	for _, e := range entries {
+		// the user's time entries of all workspaces are returned
+		if e.Wid != wid {
+			continue
+		}
+
+		if e.ServerDeletedAt != nil {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM time_entries WHERE id = ?`, e.ID); err != nil {
+				return fmt.Errorf("error deleting time entry %d: %v", e.ID, err)
+			}
+			stats.Deleted++
+			continue
+		}
+
+		tags, err := json.Marshal(e.Tags)
+		if err != nil {
+			return err
+		}
+		if e.Tags == nil {
+			tags = []byte("[]")
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO time_entries (id, workspace_id, project_id, task_id, description, start, stop, duration, billable, tags, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (id) DO UPDATE SET
+				workspace_id = excluded.workspace_id,
+				project_id = excluded.project_id,
+				task_id = excluded.task_id,
+				description = excluded.description,
+				start = excluded.start,
+				stop = excluded.stop,
+				duration = excluded.duration,
+				billable = excluded.billable,
+				tags = excluded.tags,
+				updated_at = excluded.updated_at`,
+			e.ID, wid, nullInt(e.Pid), nullInt(e.Tid), e.Description,
+			nullTime(e.Start), nullTime(e.Stop), e.Duration, e.Billable, string(tags), nullTimestamp(e.At))
+		if err != nil {
+			return fmt.Errorf("error syncing time entry %d: %v", e.ID, err)
+		}
+		stats.TimeEntries++
+	}
+
+	return nil
+}
+
+func nullInt(v *int) sql.NullInt64 {
+	if v == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: int64(*v), Valid: true}
+}
+
+func nullTime(t *time.Time) sql.NullString {
+	if t == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: t.UTC().Format(time.RFC3339), Valid: true}
+}
+
+func nullTimestamp(t *toggl.Timestamp) sql.NullString {
+	if t == nil {
+		return sql.NullString{}
+	}
+	return nullTime(&t.Time)
+}
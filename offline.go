@@ -0,0 +1,391 @@
+package toggl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrUnreachable is wrapped by the errors of requests that did not get a
+// response from the API.
+var ErrUnreachable = errors.New("toggl API unreachable")
+
+// offlineRetryMax is the number of retries of requests in offline mode.
+const offlineRetryMax = 2
+
+// OfflineMutationKind is the kind of a journaled mutation.
+type OfflineMutationKind string
+
+// Journaled mutations
+const (
+	OfflineCreate OfflineMutationKind = "create"
+	OfflineStop   OfflineMutationKind = "stop"
+	OfflineUpdate OfflineMutationKind = "update"
+	OfflineTag    OfflineMutationKind = "tag"
+	OfflineDelete OfflineMutationKind = "delete"
+)
+
+// OfflineMutation is a time entry change journaled while the API was
+// unreachable.
+type OfflineMutation struct {
+	Kind OfflineMutationKind `json:"kind"`
+	// Entry is the created or updated entry, or the entry as it was known
+	// before being stopped, tagged or deleted. Entries created offline have a
+	// negative temporary ID.
+	Entry TimeEntry `json:"entry"`
+	// Tag and AddTag describe tag changes.
+	Tag    string `json:"tag,omitempty"`
+	AddTag bool   `json:"add_tag,omitempty"`
+	// QueuedAt is when the mutation was journaled, which is also the stop
+	// time of stopped entries.
+	QueuedAt time.Time `json:"queued_at"`
+}
+
+// ReplayConflict is a journaled mutation that was not replayed because the
+// entry changed on the server after it was journaled.
+type ReplayConflict struct {
+	Mutation OfflineMutation
+	Server   TimeEntry
+}
+
+// ReplayFailure is a journaled mutation rejected by the API.
+type ReplayFailure struct {
+	Mutation OfflineMutation
+	Err      error
+}
+
+// ReplayResult describes a replay of the offline journal.
+type ReplayResult struct {
+	Applied   int
+	Conflicts []ReplayConflict
+	Failures  []ReplayFailure
+	// Pending is the number of mutations left in the journal because the
+	// API became unreachable again.
+	Pending int
+}
+
+// ReplayError is returned by a mutation when replaying the journal before it
+// dropped conflicting or failed mutations. The mutation itself was only
+// applied if it was replayed from the journal, as mutations of entries
+// created offline are.
+type ReplayError struct {
+	Result ReplayResult
+}
+
+func (e *ReplayError) Error() string {
+	return fmt.Sprintf("offline journal replayed with %d conflicts and %d failures", len(e.Result.Conflicts), len(e.Result.Failures))
+}
+
+// Unwrap returns the errors of the failed mutations.
+func (e *ReplayError) Unwrap() []error {
+	errs := make([]error, len(e.Result.Failures))
+	for i, f := range e.Result.Failures {
+		errs[i] = f.Err
+	}
+	return errs
+}
+
+// offlineJournal is the on-disk queue of offline mutations.
+type offlineJournal struct {
+	path  string
+	mutex sync.Mutex
+
+	NextTempID int               `json:"next_temp_id"`
+	Mutations  []OfflineMutation `json:"mutations"`
+	// IDs maps temporary IDs to the IDs of the replayed entries.
+	IDs map[int]int `json:"ids"`
+	// At maps the IDs of the entries changed by the replayed mutations to
+	// their update time, so that later mutations of an entry are not taken
+	// for conflicts with the earlier ones.
+	At map[int]time.Time `json:"at,omitempty"`
+}
+
+// EnableOffline journals time entry mutations (starts, stops, updates, tag
+// changes, creations and deletions) in a file when the API is unreachable,
+// instead of failing. Entries created offline get negative temporary IDs,
+// which can be used for later mutations. The journal is replayed, in order,
+// before the next mutation made while the API is reachable, or explicitly
+// with Replay. When that replay drops conflicting or failed mutations, the
+// next mutation returns a *ReplayError describing them instead of being
+// applied.
+//
+// In offline mode, requests are retried fewer times before being considered
+// unreachable.
+func (session *Session) EnableOffline(journalPath string) error {
+	journal := &offlineJournal{path: journalPath, NextTempID: -1, IDs: make(map[int]int), At: make(map[int]time.Time)}
+
+	data, err := os.ReadFile(journalPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, journal); err != nil {
+			return fmt.Errorf("error decoding offline journal: %v", err)
+		}
+		if journal.IDs == nil {
+			journal.IDs = make(map[int]int)
+		}
+		if journal.At == nil {
+			journal.At = make(map[int]time.Time)
+		}
+	}
+
+	session.offline = journal
+	return nil
+}
+
+// DisableOffline stops journaling mutations. Pending mutations stay in the
+// journal file until offline mode is enabled again and they are replayed.
+func (session *Session) DisableOffline() {
+	session.offline = nil
+}
+
+// PendingMutations returns the journaled mutations not replayed yet.
+func (session *Session) PendingMutations() []OfflineMutation {
+	if session.offline == nil {
+		return nil
+	}
+
+	session.offline.mutex.Lock()
+	defer session.offline.mutex.Unlock()
+
+	return append([]OfflineMutation{}, session.offline.Mutations...)
+}
+
+// ResolveTempID returns the ID of the entry created when replaying the
+// creation of the entry with the given temporary ID.
+func (session *Session) ResolveTempID(id int) (int, bool) {
+	if id >= 0 {
+		return id, true
+	}
+	if session.offline == nil {
+		return 0, false
+	}
+
+	session.offline.mutex.Lock()
+	defer session.offline.mutex.Unlock()
+
+	realID, ok := session.offline.IDs[id]
+	return realID, ok
+}
+
+// Replay applies the journaled mutations in order. A mutation on an entry
+// that was updated on the server after the mutation was journaled is not
+// applied, and reported as a conflict. Mutations rejected by the API are
+// dropped and reported as failures. Replay stops if the API becomes
+// unreachable, leaving the remaining mutations in the journal.
+func (session *Session) Replay() (ReplayResult, error) {
+	var result ReplayResult
+	journal := session.offline
+	if journal == nil {
+		return result, nil
+	}
+
+	journal.mutex.Lock()
+	defer journal.mutex.Unlock()
+
+	for len(journal.Mutations) > 0 {
+		m := journal.Mutations[0]
+
+		conflict, err := session.replay(journal, m)
+		if errors.Is(err, ErrUnreachable) {
+			result.Pending = len(journal.Mutations)
+			return result, err
+		}
+
+		switch {
+		case err != nil:
+			session.logger.Debug("offline mutation failed", "mutation", m, "error", err)
+			result.Failures = append(result.Failures, ReplayFailure{Mutation: m, Err: err})
+		case conflict != nil:
+			session.logger.Debug("offline mutation conflicts", "mutation", m)
+			result.Conflicts = append(result.Conflicts, *conflict)
+		default:
+			result.Applied++
+		}
+
+		journal.Mutations = journal.Mutations[1:]
+		if len(journal.Mutations) == 0 {
+			journal.At = make(map[int]time.Time)
+		}
+		if err := journal.save(); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// replay applies a single journaled mutation.
+func (session *Session) replay(journal *offlineJournal, m OfflineMutation) (*ReplayConflict, error) {
+	entry := m.Entry
+	// the journaled tag IDs were set by the caller, even once decoded
+	entry.decodedTagIDs = nil
+
+	if m.Kind == OfflineCreate {
+		created, err := session.postTimeEntry(newCreateEntryRequestData(entry))
+		if err != nil {
+			return nil, err
+		}
+		if entry.ID < 0 {
+			journal.IDs[entry.ID] = created.ID
+		}
+		journal.applied(created)
+		return nil, nil
+	}
+
+	tempID := entry.ID
+	if entry.ID < 0 {
+		id, ok := journal.IDs[entry.ID]
+		if !ok {
+			return nil, fmt.Errorf("time entry with temporary ID %d was never created", entry.ID)
+		}
+		entry.ID = id
+	}
+
+	// the entry is compared with its state after the mutations replayed
+	// before, if any
+	at := entry.At.timePtr()
+	if applied, ok := journal.At[entry.ID]; ok {
+		at = &applied
+	}
+
+	// entries created offline cannot have been changed by anyone else
+	if tempID > 0 && m.Kind != OfflineTag && at != nil {
+		server, err := session.GetTimeEntry(entry.ID)
+		if err != nil {
+			return nil, err
+		}
+		if server.At != nil && server.At.After(*at) {
+			return &ReplayConflict{Mutation: m, Server: server}, nil
+		}
+	}
+
+	var updated TimeEntry
+	var err error
+	switch m.Kind {
+	case OfflineStop:
+		stop := m.QueuedAt
+		entry.Stop = &stop
+		entry.Duration = int64(stop.Sub(entry.StartTime()) / time.Second)
+		updated, err = session.putTimeEntry(entry)
+	case OfflineUpdate:
+		updated, err = session.putTimeEntry(entry)
+	case OfflineTag:
+		action := "add"
+		if !m.AddTag {
+			action = "remove"
+		}
+		updated, err = session.changeTag(entry.ID, m.Tag, action, entry.Wid)
+	case OfflineDelete:
+		_, err = session.deleteTimeEntry(entry)
+		delete(journal.At, entry.ID)
+		return nil, err
+	default:
+		err = fmt.Errorf("unknown offline mutation %q", m.Kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	journal.applied(updated)
+	return nil, nil
+}
+
+// applied records the update time of an entry changed by a replayed
+// mutation.
+func (j *offlineJournal) applied(entry TimeEntry) {
+	if entry.At != nil {
+		j.At[entry.ID] = entry.At.Time
+	}
+}
+
+// offlineMutation applies a mutation, journaling it instead if the API is
+// unreachable or if older mutations are still pending.
+func (session *Session) offlineMutation(m OfflineMutation, apply func() (TimeEntry, error)) (TimeEntry, error) {
+	if session.offline == nil {
+		return apply()
+	}
+
+	if len(session.PendingMutations()) > 0 {
+		result, err := session.Replay()
+		if err != nil {
+			return session.offline.enqueue(m)
+		}
+		// the caller decides what to do about the dropped mutations
+		// before changing anything else
+		if len(result.Conflicts) > 0 || len(result.Failures) > 0 {
+			return TimeEntry{}, &ReplayError{Result: result}
+		}
+	}
+
+	// mutations on entries created offline are replayed to resolve their
+	// temporary ID
+	if m.Entry.ID < 0 {
+		entry, err := session.offline.enqueue(m)
+		if err != nil {
+			return entry, err
+		}
+
+		result, err := session.Replay()
+		if err == nil && (len(result.Conflicts) > 0 || len(result.Failures) > 0) {
+			return TimeEntry{}, &ReplayError{Result: result}
+		}
+		return entry, nil
+	}
+
+	entry, err := apply()
+	if errors.Is(err, ErrUnreachable) {
+		session.logger.Debug("API unreachable, journaling mutation", "mutation", m)
+		return session.offline.enqueue(m)
+	}
+
+	return entry, err
+}
+
+// enqueue journals a mutation and returns the entry as it will be once the
+// mutation is replayed.
+func (j *offlineJournal) enqueue(m OfflineMutation) (TimeEntry, error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	m.QueuedAt = time.Now()
+	entry := m.Entry
+	// the journal only keeps the tag IDs set by the caller
+	m.Entry.TagIDs = m.Entry.changedTagIDs()
+
+	switch m.Kind {
+	case OfflineCreate:
+		entry.ID = j.NextTempID
+		j.NextTempID--
+		m.Entry.ID = entry.ID
+	case OfflineStop:
+		stop := m.QueuedAt
+		entry.Stop = &stop
+		entry.Duration = int64(stop.Sub(entry.StartTime()) / time.Second)
+	}
+
+	j.Mutations = append(j.Mutations, m)
+	if err := j.save(); err != nil {
+		return TimeEntry{}, fmt.Errorf("error journaling offline mutation: %v", err)
+	}
+
+	return entry, nil
+}
+
+// save writes the journal atomically.
+func (j *offlineJournal) save() error {
+	data, err := json.Marshal(j)
+	if err != nil {
+		return err
+	}
+
+	tmp := j.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, j.path)
+}
@@ -0,0 +1,433 @@
+/*
+Package parse turns short free text into time entries.
+
+	entry, err := parse.Parse("2h on @website #design yesterday 14:00 billable", parse.Options{Location: loc})
+	...
+	timer, err := entry.Resolve(&session, wid)
+	...
+	timer, err = session.CreateTimeEntry(timer)
+
+The text is made of words, in any order:
+
+	@project, @"two words"   the project
+	#tag, #"two words"       a tag, several can be given
+	$, $billable, billable   a billable entry
+	2h, 45m, 1h30m, 1.5h     the duration
+	today, yesterday,        the day; weekdays stand for the last such day,
+	tomorrow, monday, mon,   today included
+	2026-10-17
+	14:00, 2pm, 2:30pm       the start time
+	9:00-11:30, 9am-11am     the start and stop times
+
+The other words make the description, except the words "on", "at", "for" and
+"from" before a recognized word. Without a start time, an entry with a
+duration ends now, or at the same time of day on another day. Without a
+duration, the entry is running.
+*/
+package parse
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/leucos/go-toggl"
+)
+
+// Error is an error at a position of the parsed text.
+type Error struct {
+	// Pos is the byte offset of the faulty word in the text.
+	Pos  int
+	Word string
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("column %d: %s: %q", e.Pos+1, e.Msg, e.Word)
+}
+
+// Options are the parsing options.
+type Options struct {
+	// Location is the time zone of days and times, time.Local if nil.
+	Location *time.Location
+
+	// Now is the reference time of relative days, time.Now() if zero.
+	Now time.Time
+}
+
+// Entry is a parsed time entry.
+type Entry struct {
+	Description string
+	Project     string
+	Tags        []string
+	Billable    bool
+	Start       time.Time
+
+	// Duration is zero for running entries.
+	Duration time.Duration
+}
+
+// IsRunning reports whether the entry has no duration.
+func (e Entry) IsRunning() bool {
+	return e.Duration == 0
+}
+
+// TimeEntry returns the time entry to create in workspace wid, without its
+// project.
+func (e Entry) TimeEntry(wid int) toggl.TimeEntry {
+	start := e.Start
+	timer := toggl.TimeEntry{
+		Wid:         wid,
+		Description: e.Description,
+		Tags:        e.Tags,
+		Billable:    e.Billable,
+		Start:       &start,
+		Duration:    -1,
+	}
+	if !e.IsRunning() {
+		stop := start.Add(e.Duration)
+		timer.Stop = &stop
+		timer.Duration = int64(e.Duration / time.Second)
+	}
+	return timer
+}
+
+// Resolve returns the time entry to create in workspace wid, finding its
+// project by name.
+func (e Entry) Resolve(session *toggl.Session, wid int) (toggl.TimeEntry, error) {
+	timer := e.TimeEntry(wid)
+	if e.Project != "" {
+		project, err := session.FindProjectByName(wid, e.Project)
+		if err != nil {
+			return toggl.TimeEntry{}, err
+		}
+		timer.Pid = &project.ID
+	}
+	return timer, nil
+}
+
+type word struct {
+	text string
+	pos  int
+}
+
+type clock struct {
+	hour, min int
+}
+
+// parser holds the words recognized so far.
+type parser struct {
+	entry       Entry
+	project     *word
+	duration    *word
+	day         *word
+	date        time.Time
+	start, stop *clock
+	times       *word
+	description []string
+}
+
+var fillers = map[string]bool{"on": true, "at": true, "for": true, "from": true}
+
+// Parse parses a time entry from text.
+func Parse(text string, opts Options) (Entry, error) {
+	loc := opts.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	now = now.In(loc)
+
+	words, err := split(text)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	p := &parser{}
+	recognized := make([]bool, len(words))
+	for i, w := range words {
+		if recognized[i], err = p.word(w, now); err != nil {
+			return Entry{}, err
+		}
+	}
+
+	for i, w := range words {
+		if recognized[i] {
+			continue
+		}
+		next := i + 1
+		if fillers[strings.ToLower(w.text)] && next < len(words) && recognized[next] {
+			continue
+		}
+		p.description = append(p.description, w.text)
+	}
+
+	return p.build(now)
+}
+
+// split splits text into words, keeping the quoted names of projects and
+// tags whole.
+func split(text string) ([]word, error) {
+	var words []word
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if unicode.IsSpace(r) {
+			i += size
+			continue
+		}
+
+		start := i
+		if (r == '@' || r == '#') && i+1 < len(text) && (text[i+1] == '"' || text[i+1] == '\'') {
+			quote := text[i+1]
+			end := strings.IndexByte(text[i+2:], quote)
+			if end < 0 {
+				return nil, &Error{Pos: start, Word: text[start:], Msg: "unterminated quote"}
+			}
+			words = append(words, word{text: text[i:i+1] + text[i+2:i+2+end], pos: start})
+			i += end + 3
+			continue
+		}
+
+		for i < len(text) {
+			r, size := utf8.DecodeRuneInString(text[i:])
+			if unicode.IsSpace(r) {
+				break
+			}
+			i += size
+		}
+		words = append(words, word{text: text[start:i], pos: start})
+	}
+	return words, nil
+}
+
+// word records a word, reporting whether it was recognized.
+func (p *parser) word(w word, now time.Time) (bool, error) {
+	lower := strings.ToLower(w.text)
+
+	switch {
+	case strings.HasPrefix(w.text, "@"):
+		if len(w.text) == 1 {
+			return false, &Error{Pos: w.pos, Word: w.text, Msg: "missing project name"}
+		}
+		if p.project != nil {
+			return false, &Error{Pos: w.pos, Word: w.text, Msg: "project given twice"}
+		}
+		p.project = &w
+		p.entry.Project = w.text[1:]
+		return true, nil
+
+	case strings.HasPrefix(w.text, "#"):
+		if len(w.text) == 1 {
+			return false, &Error{Pos: w.pos, Word: w.text, Msg: "missing tag name"}
+		}
+		p.entry.Tags = append(p.entry.Tags, w.text[1:])
+		return true, nil
+
+	case lower == "$" || lower == "$billable" || lower == "billable":
+		p.entry.Billable = true
+		return true, nil
+
+	case strings.HasPrefix(w.text, "$"):
+		return false, &Error{Pos: w.pos, Word: w.text, Msg: "unknown marker"}
+	}
+
+	if d, ok := parseDuration(lower); ok {
+		if p.duration != nil || p.stop != nil {
+			return false, &Error{Pos: w.pos, Word: w.text, Msg: "duration given twice"}
+		}
+		if d <= 0 {
+			return false, &Error{Pos: w.pos, Word: w.text, Msg: "invalid duration"}
+		}
+		p.duration = &w
+		p.entry.Duration = d
+		return true, nil
+	}
+
+	if date, ok := parseDay(lower, now); ok || datePattern.MatchString(lower) {
+		if !ok {
+			return false, &Error{Pos: w.pos, Word: w.text, Msg: "invalid date"}
+		}
+		if p.day != nil {
+			return false, &Error{Pos: w.pos, Word: w.text, Msg: "day given twice"}
+		}
+		p.day = &w
+		p.date = date
+		return true, nil
+	}
+
+	if from, to, ok := strings.Cut(lower, "-"); ok {
+		start, okStart := parseClock(from)
+		stop, okStop := parseClock(to)
+		if okStart && okStop {
+			if p.times != nil {
+				return false, &Error{Pos: w.pos, Word: w.text, Msg: "time given twice"}
+			}
+			if p.duration != nil {
+				return false, &Error{Pos: w.pos, Word: w.text, Msg: "duration given twice"}
+			}
+			p.times = &w
+			p.start, p.stop = &start, &stop
+			return true, nil
+		}
+	}
+
+	if looksLikeClock(lower) {
+		start, ok := parseClock(lower)
+		if !ok {
+			return false, &Error{Pos: w.pos, Word: w.text, Msg: "invalid time"}
+		}
+		if p.times != nil {
+			return false, &Error{Pos: w.pos, Word: w.text, Msg: "time given twice"}
+		}
+		p.times = &w
+		p.start = &start
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// build computes the times of the entry.
+func (p *parser) build(now time.Time) (Entry, error) {
+	entry := p.entry
+	entry.Description = strings.Join(p.description, " ")
+
+	today := day(now)
+	date := today
+	if p.day != nil {
+		date = p.date
+	}
+	at := func(c clock) time.Time {
+		return time.Date(date.Year(), date.Month(), date.Day(), c.hour, c.min, 0, 0, now.Location())
+	}
+
+	switch {
+	case p.start != nil && p.stop != nil:
+		entry.Start = at(*p.start)
+		stop := at(*p.stop)
+		if !stop.After(entry.Start) {
+			stop = stop.AddDate(0, 0, 1)
+		}
+		entry.Duration = stop.Sub(entry.Start)
+
+	case p.start != nil:
+		entry.Start = at(*p.start)
+		if entry.IsRunning() && entry.Start.After(now) {
+			return Entry{}, &Error{Pos: p.times.pos, Word: p.times.text, Msg: "running entry starting in the future"}
+		}
+
+	case !entry.IsRunning():
+		stop := now
+		if !date.Equal(today) {
+			stop = time.Date(date.Year(), date.Month(), date.Day(), now.Hour(), now.Minute(), 0, 0, now.Location())
+		}
+		entry.Start = stop.Add(-entry.Duration)
+
+	default:
+		if p.day != nil && !date.Equal(today) {
+			return Entry{}, &Error{Pos: p.day.pos, Word: p.day.text, Msg: "day without a time or duration"}
+		}
+		entry.Start = now
+	}
+
+	return entry, nil
+}
+
+var durationUnits = strings.NewReplacer("hours", "h", "hour", "h", "hrs", "h", "hr", "h", "mins", "m", "min", "m")
+
+var durationPattern = regexp.MustCompile(`^(\d+(\.\d+)?h)?(\d+(\.\d+)?m)?$`)
+
+// parseDuration parses durations such as 2h, 45m, 1h30m, 1.5h or 90min.
+func parseDuration(s string) (time.Duration, bool) {
+	s = durationUnits.Replace(s)
+	if s == "" || !durationPattern.MatchString(s) {
+		return 0, false
+	}
+	d, err := time.ParseDuration(s)
+	return d, err == nil
+}
+
+var weekdays = map[string]time.Weekday{}
+
+func init() {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := strings.ToLower(d.String())
+		weekdays[name] = d
+		weekdays[name[:3]] = d
+	}
+}
+
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// parseDay parses relative days, weekdays and dates as 2006-01-02.
+func parseDay(s string, now time.Time) (time.Time, bool) {
+	today := day(now)
+
+	switch s {
+	case "today":
+		return today, true
+	case "yesterday":
+		return today.AddDate(0, 0, -1), true
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), true
+	}
+
+	if wd, ok := weekdays[s]; ok {
+		offset := (int(today.Weekday()) - int(wd) + 7) % 7
+		return today.AddDate(0, 0, -offset), true
+	}
+
+	if t, err := time.ParseInLocation("2006-01-02", s, now.Location()); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+var datePattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+
+var clockPattern = regexp.MustCompile(`^(\d{1,2})(:(\d{2}))?(am|pm)?$`)
+
+// looksLikeClock reports whether s is meant as a time of day, valid or not.
+func looksLikeClock(s string) bool {
+	m := clockPattern.FindStringSubmatch(s)
+	return m != nil && (m[2] != "" || m[4] != "")
+}
+
+// parseClock parses times of day such as 14:00, 2pm or 2:30pm.
+func parseClock(s string) (clock, bool) {
+	m := clockPattern.FindStringSubmatch(s)
+	if m == nil || (m[2] == "" && m[4] == "") {
+		return clock{}, false
+	}
+
+	hour, _ := strconv.Atoi(m[1])
+	minute := 0
+	if m[3] != "" {
+		minute, _ = strconv.Atoi(m[3])
+	}
+
+	switch m[4] {
+	case "am", "pm":
+		if hour < 1 || hour > 12 {
+			return clock{}, false
+		}
+		hour %= 12
+		if m[4] == "pm" {
+			hour += 12
+		}
+	}
+
+	if hour > 23 || minute > 59 {
+		return clock{}, false
+	}
+	return clock{hour, minute}, true
+}
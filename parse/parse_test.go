@@ -0,0 +1,92 @@
+package parse
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	loc := time.FixedZone("CEST", 2*60*60)
+	now := time.Date(2026, 10, 17, 15, 30, 0, 0, loc) // a Saturday
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2026, 10, day, hour, min, 0, 0, loc)
+	}
+
+	tests := []struct {
+		text string
+		want Entry
+	}{
+		{"réunion à Paris 2h", Entry{Description: "réunion à Paris", Start: at(17, 13, 30), Duration: 2 * time.Hour}},
+		{"Åsa sync 1h #ops", Entry{Description: "Åsa sync", Tags: []string{"ops"}, Start: at(17, 14, 30), Duration: time.Hour}},
+		{"café crème 30m", Entry{Description: "café crème", Start: at(17, 15, 0), Duration: 30 * time.Minute}},
+		{`2h on @"Web site" #'deep work' #ops`, Entry{Project: "Web site", Tags: []string{"deep work", "ops"}, Start: at(17, 13, 30), Duration: 2 * time.Hour}},
+		{`@"Développement" naïve bug`, Entry{Description: "naïve bug", Project: "Développement", Start: now}},
+		{"write docs", Entry{Description: "write docs", Start: now}},
+		{"café 14:00", Entry{Description: "café", Start: at(17, 14, 0)}},
+		{"standup 9:00-9:15 yesterday", Entry{Description: "standup", Start: at(16, 9, 0), Duration: 15 * time.Minute}},
+		{"night shift 10pm-6am fri", Entry{Description: "night shift", Start: at(16, 22, 0), Duration: 8 * time.Hour}},
+		{"review 45m monday", Entry{Description: "review", Start: at(12, 14, 45), Duration: 45 * time.Minute}},
+		{"1h30m review $ at 2026-10-01 9am", Entry{Description: "review", Billable: true, Start: at(1, 9, 0), Duration: 90 * time.Minute}},
+		{"pair\u00a0review\u2003 1h", Entry{Description: "pair review", Start: at(17, 14, 30), Duration: time.Hour}},
+		{"on call", Entry{Description: "on call", Start: now}},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.text, Options{Location: loc, Now: now})
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.text, err)
+			continue
+		}
+		if got.Description != tt.want.Description || got.Project != tt.want.Project ||
+			!slices.Equal(got.Tags, tt.want.Tags) || got.Billable != tt.want.Billable ||
+			!got.Start.Equal(tt.want.Start) || got.Duration != tt.want.Duration {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	loc := time.FixedZone("CEST", 2*60*60)
+	now := time.Date(2026, 10, 17, 15, 30, 0, 0, loc)
+
+	tests := []struct {
+		text string
+		want Error
+	}{
+		{`réunion @"Web site`, Error{Pos: 9, Word: `@"Web site`, Msg: "unterminated quote"}},
+		{`#'deep work`, Error{Pos: 0, Word: `#'deep work`, Msg: "unterminated quote"}},
+		{"@a é @b", Error{Pos: 6, Word: "@b", Msg: "project given twice"}},
+		{"é 2h 3h", Error{Pos: 6, Word: "3h", Msg: "duration given twice"}},
+		{"9:00-10:00 1h", Error{Pos: 11, Word: "1h", Msg: "duration given twice"}},
+		{"déjà 25:00", Error{Pos: 7, Word: "25:00", Msg: "invalid time"}},
+		{"9am 10am", Error{Pos: 4, Word: "10am", Msg: "time given twice"}},
+		{"2026-13-01", Error{Pos: 0, Word: "2026-13-01", Msg: "invalid date"}},
+		{"today yesterday", Error{Pos: 6, Word: "yesterday", Msg: "day given twice"}},
+		{"ça @", Error{Pos: 4, Word: "@", Msg: "missing project name"}},
+		{"#", Error{Pos: 0, Word: "#", Msg: "missing tag name"}},
+		{"$foo", Error{Pos: 0, Word: "$foo", Msg: "unknown marker"}},
+		{"later 16:00", Error{Pos: 6, Word: "16:00", Msg: "running entry starting in the future"}},
+		{"yesterday", Error{Pos: 0, Word: "yesterday", Msg: "day without a time or duration"}},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse(tt.text, Options{Location: loc, Now: now})
+		var got *Error
+		if !errors.As(err, &got) {
+			t.Errorf("Parse(%q) error = %v, want %v", tt.text, err, &tt.want)
+			continue
+		}
+		if *got != tt.want {
+			t.Errorf("Parse(%q) error = %+v, want %+v", tt.text, *got, tt.want)
+		}
+	}
+}
+
+func TestErrorString(t *testing.T) {
+	err := &Error{Pos: 9, Word: `@"Web site`, Msg: "unterminated quote"}
+	if want := `column 10: unterminated quote: "@\"Web site"`; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
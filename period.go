@@ -0,0 +1,77 @@
+package toggl
+
+import (
+	"fmt"
+	"time"
+)
+
+// Period is a range of time, from Start included to End excluded. The
+// period package builds the usual ones, such as today or last week.
+type Period struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Contains reports whether t is within the period.
+func (p Period) Contains(t time.Time) bool {
+	return !t.Before(p.Start) && t.Before(p.End)
+}
+
+// Duration returns the length of the period.
+func (p Period) Duration() time.Duration {
+	return p.End.Sub(p.Start)
+}
+
+func (p Period) String() string {
+	return fmt.Sprintf("%s - %s", p.Start.Format(time.RFC3339), p.End.Format(time.RFC3339))
+}
+
+// IsZero reports whether the period is the zero Period.
+func (p Period) IsZero() bool {
+	return p.Start.IsZero() && p.End.IsZero()
+}
+
+// Last returns the last instant of the period, for the APIs taking ranges
+// that include their end, such as the reports API which takes days.
+func (p Period) Last() time.Time {
+	return p.End.Add(-time.Nanosecond)
+}
+
+// GetTimeEntriesIn returns the time entries started within a period.
+func (session *Session) GetTimeEntriesIn(p Period) ([]TimeEntry, error) {
+	return session.GetTimeEntries(p.Start, p.End)
+}
+
+// GetTimeEntriesChangedIn returns the time entries of the user created,
+// updated or deleted within a period, as GetTimeEntriesSince.
+func (session *Session) GetTimeEntriesChangedIn(p Period) ([]TimeEntry, error) {
+	entries, err := session.GetTimeEntriesSince(p.Start)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := make([]TimeEntry, 0, len(entries))
+	for _, e := range entries {
+		if e.At == nil || p.Contains(e.At.Time) {
+			changed = append(changed, e)
+		}
+	}
+	return changed, nil
+}
+
+// GetSummaryReportIn retrieves the summary report of the days of a period.
+func (session *Session) GetSummaryReportIn(wid int, p Period) (SummaryReport, error) {
+	return session.GetSummaryReport(wid, session.reportDate(p.Start), session.reportDate(p.Last()))
+}
+
+// GetDetailedReportIn retrieves a page of the detailed report of the days of
+// a period.
+func (session *Session) GetDetailedReportIn(wid int, p Period, page int) (DetailedReport, error) {
+	return session.GetDetailedReport(wid, session.reportDate(p.Start), session.reportDate(p.Last()), page)
+}
+
+// ForEachDetailedTimeEntryIn calls fn for every time entry tracked in a
+// workspace during the days of a period, as ForEachDetailedTimeEntry.
+func (session *Session) ForEachDetailedTimeEntryIn(wid int, p Period, fn func(DetailedTimeEntry) error) error {
+	return session.ForEachDetailedTimeEntry(wid, p.Start, p.Last(), fn)
+}
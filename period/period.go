@@ -0,0 +1,260 @@
+/*
+Package period builds the usual periods of time, such as today, last week or
+a given month, in the user's time zone and following the user's first day of
+the week.
+
+	cal := period.For(&session)
+	report, err := session.GetSummaryReportIn(wid, cal.LastWeek())
+	...
+	p, err := cal.Parse("2026-W41")
+	entries, err := session.GetTimeEntriesIn(p)
+*/
+package period
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/leucos/go-toggl"
+)
+
+// Calendar computes periods in a time zone with a first day of the week.
+type Calendar struct {
+	Location  *time.Location
+	WeekStart time.Weekday
+
+	// Now returns the current time, time.Now if nil.
+	Now func() time.Time
+}
+
+// For returns the calendar of the user of a session, with the time zone and
+// first day of the week of the account.
+func For(session *toggl.Session) Calendar {
+	return Calendar{Location: session.Location(), WeekStart: session.WeekStart()}
+}
+
+func (c Calendar) now() time.Time {
+	now := time.Now
+	if c.Now != nil {
+		now = c.Now
+	}
+	loc := c.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	return now().In(loc)
+}
+
+func (c Calendar) date(year int, month time.Month, day int) time.Time {
+	loc := c.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
+
+func (c Calendar) today() time.Time {
+	now := c.now()
+	return c.date(now.Year(), now.Month(), now.Day())
+}
+
+// days returns the period of n days from start.
+func days(start time.Time, n int) toggl.Period {
+	return toggl.Period{Start: start, End: start.AddDate(0, 0, n)}
+}
+
+// Day returns the period of a day.
+func (c Calendar) Day(year int, month time.Month, day int) toggl.Period {
+	return days(c.date(year, month, day), 1)
+}
+
+// Today returns the period of today.
+func (c Calendar) Today() toggl.Period {
+	return days(c.today(), 1)
+}
+
+// Yesterday returns the period of yesterday.
+func (c Calendar) Yesterday() toggl.Period {
+	return days(c.today().AddDate(0, 0, -1), 1)
+}
+
+// LastNDays returns the period of the last n days, today included.
+func (c Calendar) LastNDays(n int) toggl.Period {
+	return toggl.Period{Start: c.today().AddDate(0, 0, 1-n), End: c.today().AddDate(0, 0, 1)}
+}
+
+// weekStart returns the start of the week of day.
+func (c Calendar) weekStart(day time.Time) time.Time {
+	offset := (int(day.Weekday()) - int(c.WeekStart) + 7) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+// ThisWeek returns the period of the current week.
+func (c Calendar) ThisWeek() toggl.Period {
+	return days(c.weekStart(c.today()), 7)
+}
+
+// LastWeek returns the period of the previous week.
+func (c Calendar) LastWeek() toggl.Period {
+	return days(c.weekStart(c.today()).AddDate(0, 0, -7), 7)
+}
+
+// Week returns the period of an ISO 8601 week, starting on the first day of
+// the week of the calendar: when it is not Monday, the period starts on the
+// last such day before the Monday of the ISO week.
+func (c Calendar) Week(year, week int) toggl.Period {
+	// January 4th is always in the first ISO week
+	jan4 := c.date(year, time.January, 4)
+	monday := jan4.AddDate(0, 0, -((int(jan4.Weekday())+6)%7)+7*(week-1))
+	return days(c.weekStart(monday), 7)
+}
+
+// Month returns the period of a month.
+func (c Calendar) Month(year int, month time.Month) toggl.Period {
+	start := c.date(year, month, 1)
+	return toggl.Period{Start: start, End: start.AddDate(0, 1, 0)}
+}
+
+// ThisMonth returns the period of the current month.
+func (c Calendar) ThisMonth() toggl.Period {
+	now := c.now()
+	return c.Month(now.Year(), now.Month())
+}
+
+// LastMonth returns the period of the previous month.
+func (c Calendar) LastMonth() toggl.Period {
+	now := c.now()
+	return c.Month(now.Year(), now.Month()-1)
+}
+
+// Quarter returns the period of a quarter, from 1 to 4.
+func (c Calendar) Quarter(year, quarter int) toggl.Period {
+	start := c.date(year, time.Month(3*(quarter-1)+1), 1)
+	return toggl.Period{Start: start, End: start.AddDate(0, 3, 0)}
+}
+
+// ThisQuarter returns the period of the current quarter.
+func (c Calendar) ThisQuarter() toggl.Period {
+	now := c.now()
+	return c.Quarter(now.Year(), (int(now.Month())-1)/3+1)
+}
+
+// Year returns the period of a year.
+func (c Calendar) Year(year int) toggl.Period {
+	start := c.date(year, time.January, 1)
+	return toggl.Period{Start: start, End: start.AddDate(1, 0, 0)}
+}
+
+var (
+	weekPattern    = regexp.MustCompile(`^(\d{4})-w(\d{1,2})$`)
+	quarterPattern = regexp.MustCompile(`^(\d{4})-q([1-4])$`)
+	monthPattern   = regexp.MustCompile(`^(\d{4})-(\d{2})$`)
+	yearPattern    = regexp.MustCompile(`^(\d{4})$`)
+	lastPattern    = regexp.MustCompile(`^(?:last-?)?(\d+)d(?:ays)?$`)
+)
+
+// Parse parses a period: "today", "yesterday", "this-week", "last-week",
+// "this-month", "last-month", "this-quarter", "this-year", "last-year",
+// a number of days such as "7d" or "last-30-days", an ISO week
+// "2026-W41", a quarter "2026-Q3", a month "2026-09", a year "2026", a
+// day "2026-09-17", or two days separated by "..", both included, as
+// "2026-09-01..2026-09-15". Spaces can replace dashes in names.
+func (c Calendar) Parse(s string) (toggl.Period, error) {
+	name := strings.ToLower(strings.Join(strings.Fields(s), "-"))
+
+	switch name {
+	case "today":
+		return c.Today(), nil
+	case "yesterday":
+		return c.Yesterday(), nil
+	case "this-week", "week":
+		return c.ThisWeek(), nil
+	case "last-week":
+		return c.LastWeek(), nil
+	case "this-month", "month":
+		return c.ThisMonth(), nil
+	case "last-month":
+		return c.LastMonth(), nil
+	case "this-quarter", "quarter":
+		return c.ThisQuarter(), nil
+	case "this-year", "year":
+		return c.Year(c.now().Year()), nil
+	case "last-year":
+		return c.Year(c.now().Year() - 1), nil
+	}
+
+	if m := lastPattern.FindStringSubmatch(strings.Replace(name, "-days", "d", 1)); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		if n < 1 {
+			return toggl.Period{}, fmt.Errorf("invalid period %q", s)
+		}
+		return c.LastNDays(n), nil
+	}
+
+	if m := weekPattern.FindStringSubmatch(name); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		week, _ := strconv.Atoi(m[2])
+		if week < 1 || week > isoWeeks(year) {
+			return toggl.Period{}, fmt.Errorf("invalid week in %q", s)
+		}
+		return c.Week(year, week), nil
+	}
+
+	if m := quarterPattern.FindStringSubmatch(name); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		quarter, _ := strconv.Atoi(m[2])
+		return c.Quarter(year, quarter), nil
+	}
+
+	if m := monthPattern.FindStringSubmatch(name); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		month, _ := strconv.Atoi(m[2])
+		if month < 1 || month > 12 {
+			return toggl.Period{}, fmt.Errorf("invalid month in %q", s)
+		}
+		return c.Month(year, time.Month(month)), nil
+	}
+
+	if m := yearPattern.FindStringSubmatch(name); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		return c.Year(year), nil
+	}
+
+	if from, to, ok := strings.Cut(name, ".."); ok {
+		first, err := c.parseDay(from)
+		if err != nil {
+			return toggl.Period{}, err
+		}
+		last, err := c.parseDay(to)
+		if err != nil {
+			return toggl.Period{}, err
+		}
+		if last.Before(first) {
+			return toggl.Period{}, fmt.Errorf("period %q ends before it starts", s)
+		}
+		return toggl.Period{Start: first, End: last.AddDate(0, 0, 1)}, nil
+	}
+
+	if day, err := c.parseDay(name); err == nil {
+		return days(day, 1), nil
+	}
+
+	return toggl.Period{}, fmt.Errorf("invalid period %q", s)
+}
+
+func (c Calendar) parseDay(s string) (time.Time, error) {
+	loc := c.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	return time.ParseInLocation("2006-01-02", s, loc)
+}
+
+// isoWeeks returns the number of ISO weeks of a year.
+func isoWeeks(year int) int {
+	_, week := time.Date(year, time.December, 28, 0, 0, 0, 0, time.UTC).ISOWeek()
+	return week
+}
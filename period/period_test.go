@@ -0,0 +1,172 @@
+package period
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/leucos/go-toggl"
+)
+
+func TestCalendar(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 10, 17, 15, 30, 0, 0, paris) // a Saturday
+	monday := Calendar{Location: paris, WeekStart: time.Monday, Now: func() time.Time { return now }}
+	sunday := monday
+	sunday.WeekStart = time.Sunday
+	january := monday
+	january.Now = func() time.Time { return time.Date(2027, 1, 10, 12, 0, 0, 0, paris) }
+
+	period := func(y1 int, m1 time.Month, d1 int, y2 int, m2 time.Month, d2 int) toggl.Period {
+		return toggl.Period{
+			Start: time.Date(y1, m1, d1, 0, 0, 0, 0, paris),
+			End:   time.Date(y2, m2, d2, 0, 0, 0, 0, paris),
+		}
+	}
+
+	tests := []struct {
+		name string
+		got  toggl.Period
+		want toggl.Period
+	}{
+		{"today", monday.Today(), period(2026, 10, 17, 2026, 10, 18)},
+		{"yesterday", monday.Yesterday(), period(2026, 10, 16, 2026, 10, 17)},
+		{"last 7 days", monday.LastNDays(7), period(2026, 10, 11, 2026, 10, 18)},
+		{"this week", monday.ThisWeek(), period(2026, 10, 12, 2026, 10, 19)},
+		{"this week from Sunday", sunday.ThisWeek(), period(2026, 10, 11, 2026, 10, 18)},
+		{"last week", monday.LastWeek(), period(2026, 10, 5, 2026, 10, 12)},
+		{"last week from Sunday", sunday.LastWeek(), period(2026, 10, 4, 2026, 10, 11)},
+		{"week 41", monday.Week(2026, 41), period(2026, 10, 5, 2026, 10, 12)},
+		{"week 41 from Sunday", sunday.Week(2026, 41), period(2026, 10, 4, 2026, 10, 11)},
+		{"week 1 starting the year before", monday.Week(2026, 1), period(2025, 12, 29, 2026, 1, 5)},
+		{"week 53", monday.Week(2026, 53), period(2026, 12, 28, 2027, 1, 4)},
+		{"week 1 of a year starting on Monday", monday.Week(2024, 1), period(2024, 1, 1, 2024, 1, 8)},
+		{"week ending the summer time", monday.Week(2026, 43), period(2026, 10, 19, 2026, 10, 26)},
+		{"this month", monday.ThisMonth(), period(2026, 10, 1, 2026, 11, 1)},
+		{"last month", monday.LastMonth(), period(2026, 9, 1, 2026, 10, 1)},
+		{"last month in January", january.LastMonth(), period(2026, 12, 1, 2027, 1, 1)},
+		{"February of a leap year", monday.Month(2028, time.February), period(2028, 2, 1, 2028, 3, 1)},
+		{"this quarter", monday.ThisQuarter(), period(2026, 10, 1, 2027, 1, 1)},
+		{"first quarter", monday.Quarter(2026, 1), period(2026, 1, 1, 2026, 4, 1)},
+		{"year", monday.Year(2026), period(2026, 1, 1, 2027, 1, 1)},
+		{"day", monday.Day(2026, 3, 29), period(2026, 3, 29, 2026, 3, 30)},
+	}
+
+	for _, tt := range tests {
+		if !tt.got.Start.Equal(tt.want.Start) || !tt.got.End.Equal(tt.want.End) {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+		if tt.got.Start.Location() != paris || tt.got.End.Location() != paris {
+			t.Errorf("%s is in %v, want %v", tt.name, tt.got.Start.Location(), paris)
+		}
+	}
+}
+
+func TestCalendarDaylightSaving(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := Calendar{Location: paris, WeekStart: time.Monday}
+
+	tests := []struct {
+		name string
+		got  toggl.Period
+		want time.Duration
+	}{
+		{"summer time start", c.Day(2026, 3, 29), 23 * time.Hour},
+		{"summer time end", c.Day(2026, 10, 25), 25 * time.Hour},
+		{"week of summer time end", c.Week(2026, 43), 7*24*time.Hour + time.Hour},
+		{"March", c.Month(2026, time.March), 31*24*time.Hour - time.Hour},
+		{"year", c.Year(2026), 365 * 24 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		if d := tt.got.Duration(); d != tt.want {
+			t.Errorf("%s lasts %v, want %v", tt.name, d, tt.want)
+		}
+	}
+}
+
+func TestCalendarTimeZone(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// still Saturday in UTC, already Sunday in Tokyo
+	now := time.Date(2026, 10, 17, 20, 0, 0, 0, time.UTC)
+	c := Calendar{Location: tokyo, WeekStart: time.Sunday, Now: func() time.Time { return now }}
+
+	today := c.Today()
+	if want := time.Date(2026, 10, 18, 0, 0, 0, 0, tokyo); !today.Start.Equal(want) {
+		t.Errorf("today starts at %v, want %v", today.Start, want)
+	}
+	if want := time.Date(2026, 10, 18, 0, 0, 0, 0, tokyo); !c.ThisWeek().Start.Equal(want) {
+		t.Errorf("this week starts at %v, want %v", c.ThisWeek().Start, want)
+	}
+	if !today.Contains(now) {
+		t.Errorf("today %v does not contain %v", today, now)
+	}
+}
+
+func TestParse(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 10, 17, 15, 30, 0, 0, paris)
+	c := Calendar{Location: paris, WeekStart: time.Monday, Now: func() time.Time { return now }}
+
+	tests := []struct {
+		text string
+		want toggl.Period
+	}{
+		{"today", c.Today()},
+		{"Yesterday", c.Yesterday()},
+		{"this week", c.ThisWeek()},
+		{"week", c.ThisWeek()},
+		{"last-week", c.LastWeek()},
+		{"last month", c.LastMonth()},
+		{"quarter", c.ThisQuarter()},
+		{"this-year", c.Year(2026)},
+		{"last year", c.Year(2025)},
+		{"7d", c.LastNDays(7)},
+		{"last-30-days", c.LastNDays(30)},
+		{"last 30 days", c.LastNDays(30)},
+		{"2026-W41", c.Week(2026, 41)},
+		{"2026-w53", c.Week(2026, 53)},
+		{"2026-Q3", c.Quarter(2026, 3)},
+		{"2026-09", c.Month(2026, time.September)},
+		{"2026", c.Year(2026)},
+		{"2026-09-17", c.Day(2026, 9, 17)},
+		{"2026-09-01..2026-09-15", toggl.Period{Start: c.Day(2026, 9, 1).Start, End: c.Day(2026, 9, 16).Start}},
+		{"2026-09-15..2026-09-15", c.Day(2026, 9, 15)},
+	}
+
+	for _, tt := range tests {
+		got, err := c.Parse(tt.text)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.text, err)
+			continue
+		}
+		if !got.Start.Equal(tt.want.Start) || !got.End.Equal(tt.want.End) {
+			t.Errorf("Parse(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	c := Calendar{Location: time.UTC}
+
+	for _, text := range []string{
+		"", "soon", "0d", "2025-W53", "2026-W54", "2026-W0", "2026-Q5", "2026-13",
+		"2026-02-30", "2026-09-15..2026-09-01", "2026-09-01..", "..2026-09-01",
+	} {
+		if p, err := c.Parse(text); err == nil {
+			t.Errorf("Parse(%q) = %v, want an error", text, p)
+		}
+	}
+}
@@ -1,19 +1,445 @@
 package toggl
 
-import "time"
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/leucos/go-toggl/resource"
+)
 
 // Project represents a project.
 type Project struct {
-	Wid             int        `json:"workspace_id"`
-	ID              int        `json:"id"`
-	Cid             *int       `json:"client_id,omitempty"`
-	Name            string     `json:"name"`
-	Active          bool       `json:"active"`
-	Billable        *bool      `json:"billable,omitempty"`
-	ServerDeletedAt *time.Time `json:"server_deleted_at,omitempty"`
+	Wid             int                 `json:"workspace_id"`
+	ID              int                 `json:"id"`
+	Cid             *int                `json:"client_id,omitempty"`
+	Name            string              `json:"name"`
+	Active          bool                `json:"active"`
+	Billable        *bool               `json:"billable,omitempty"`
+	ServerDeletedAt *Timestamp          `json:"server_deleted_at,omitempty"`
+	Color           string              `json:"color,omitempty"`
+	IsPrivate       bool                `json:"is_private"`
+	EstimatedHours  *int                `json:"estimated_hours,omitempty"`
+	ActualHours     *int                `json:"actual_hours,omitempty"`
+	ActualSeconds   *int64              `json:"actual_seconds,omitempty"`
+	Rate            *float64            `json:"rate,omitempty"`
+	Currency        *string             `json:"currency,omitempty"`
+	StartDate       string              `json:"start_date,omitempty"`
+	EndDate         *string             `json:"end_date,omitempty"`
+	Recurring       bool                `json:"recurring"`
+	Recurrences     []ProjectRecurrence `json:"recurring_parameters,omitempty"`
+	Template        *bool               `json:"template,omitempty"`
+	TemplateID      *int                `json:"template_id,omitempty"`
+	AutoEstimates   *bool               `json:"auto_estimates,omitempty"`
+	CreatedAt       *Timestamp          `json:"created_at,omitempty"`
+}
+
+// UnmarshalJSON unmarshals a Project from JSON data, accepting the timestamp
+// variants of Timestamp.
+func (p *Project) UnmarshalJSON(b []byte) error {
+	type embeddedProject Project
+	var project struct {
+		embeddedProject
+		ServerDeletedAt *Timestamp `json:"server_deleted_at"`
+		CreatedAt       *Timestamp `json:"created_at"`
+	}
+	err := json.Unmarshal(b, &project)
+	if err != nil {
+		return err
+	}
+
+	*p = Project(project.embeddedProject)
+	p.ServerDeletedAt = project.ServerDeletedAt.orNil()
+	p.CreatedAt = project.CreatedAt.orNil()
+	return nil
+}
+
+// TrackedTime returns the time tracked on the project by all users, as of
+// when it was fetched.
+func (p *Project) TrackedTime() time.Duration {
+	switch {
+	case p.ActualSeconds != nil:
+		return time.Duration(*p.ActualSeconds) * time.Second
+	case p.ActualHours != nil:
+		return time.Duration(*p.ActualHours) * time.Hour
+	}
+	return 0
+}
+
+// firstDay returns the earliest of the creation and start dates of the
+// project, or Epoch if neither is known.
+func (p *Project) firstDay() time.Time {
+	var first time.Time
+	if p.CreatedAt != nil {
+		first = p.CreatedAt.Time
+	}
+	if start, err := time.Parse("2006-01-02", p.StartDate); err == nil && (first.IsZero() || start.Before(first)) {
+		first = start
+	}
+	if first.IsZero() {
+		return Epoch
+	}
+	return first
 }
 
 // IsActive indicates whether a project exists and is active
 func (p *Project) IsActive() bool {
 	return p.Active && p.ServerDeletedAt == nil
 }
+
+// ProjectUser represents the membership of a user in a project.
+type ProjectUser struct {
+	ID      int      `json:"id,omitempty"`
+	Wid     int      `json:"workspace_id"`
+	Pid     int      `json:"project_id"`
+	UserID  int      `json:"user_id"`
+	Manager bool     `json:"manager"`
+	Rate    *float64 `json:"rate,omitempty"`
+}
+
+// ProjectState selects projects by lifecycle state in GetProjects.
+type ProjectState int
+
+const (
+	ActiveProjects ProjectState = iota
+	ArchivedProjects
+	AllProjects
+)
+
+var projectStateParams = map[ProjectState]string{
+	ActiveProjects:   "true",
+	ArchivedProjects: "false",
+	AllProjects:      "both",
+}
+
+// ProjectInUseError is returned by SafeDeleteProject when deleting a project
+// would lose tracked time.
+type ProjectInUseError struct {
+	Project     Project
+	TimeEntries int
+}
+
+func (e *ProjectInUseError) Error() string {
+	if e.TimeEntries == 0 {
+		return fmt.Sprintf("project %q has time entries dated before its creation", e.Project.Name)
+	}
+	return fmt.Sprintf("project %q has %d time entries", e.Project.Name, e.TimeEntries)
+}
+
+// getProjectsByState queries the projects of a workspace in a given state,
+// bypassing the cache which only holds active projects.
+func (session *Session) getProjectsByState(wid int, state ProjectState) ([]Project, error) {
+	data, err := session.get(
+		TogglAPI,
+		resource.GenerateResourceURL(resource.Projects, wid),
+		map[string]string{"active": projectStateParams[state]},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	plist := make([]Project, 0)
+	err = json.Unmarshal(data, &plist)
+	if err != nil {
+		return nil, err
+	}
+
+	return plist, nil
+}
+
+// GetProjectsSince returns the projects of a workspace, in any state, created,
+// updated or deleted since a given time. Deleted projects have
+// ServerDeletedAt set.
+func (session *Session) GetProjectsSince(wid int, since time.Time) ([]Project, error) {
+	data, err := session.get(
+		TogglAPI,
+		resource.GenerateResourceURL(resource.Projects, wid),
+		map[string]string{"active": projectStateParams[AllProjects], "since": fmt.Sprintf("%d", since.Unix())},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	plist := make([]Project, 0)
+	err = json.Unmarshal(data, &plist)
+	if err != nil {
+		return nil, err
+	}
+
+	return plist, nil
+}
+
+// ArchiveProject makes a project inactive, keeping its time entries.
+func (session *Session) ArchiveProject(project Project) (Project, error) {
+	session.logger.Debug("archiving project", "project", project)
+	project.Active = false
+
+	archived, err := session.UpdateProject(project)
+	if err != nil {
+		return Project{}, err
+	}
+
+	session.cache.Delete(resource.Projects, project.Wid, project.ID)
+	return archived, nil
+}
+
+// RestoreProject makes an archived project active again.
+func (session *Session) RestoreProject(project Project) (Project, error) {
+	session.logger.Debug("restoring project", "project", project)
+	project.Active = true
+	return session.UpdateProject(project)
+}
+
+// CountProjectTimeEntries returns the number of time entries tracked on a
+// project since it was created or started, which takes a reports request per
+// year of the project. Entries dated before are not counted.
+func (session *Session) CountProjectTimeEntries(project Project) (int, error) {
+	count, _, err := session.detailedReportTotals(
+		project.Wid,
+		project.firstDay(),
+		time.Now(),
+		map[string]string{"project_ids": fmt.Sprintf("%d", project.ID)},
+	)
+	return count, err
+}
+
+// SafeDeleteProject deletes a project only if no time has been tracked on it,
+// returning a *ProjectInUseError otherwise. Setting force deletes the project
+// regardless.
+func (session *Session) SafeDeleteProject(project Project, force bool) ([]byte, error) {
+	if !force {
+		count, err := session.CountProjectTimeEntries(project)
+		if err != nil {
+			return nil, fmt.Errorf("error counting time entries: %v", err)
+		}
+		// the tracked time also covers the entries dated before the
+		// project was created
+		if count > 0 || project.TrackedTime() > 0 {
+			return nil, &ProjectInUseError{Project: project, TimeEntries: count}
+		}
+	}
+
+	return session.DeleteProject(project)
+}
+
+// ProjectRecurrence describes how a recurring project repeats. Period is
+// one of "daily", "weekly", "monthly", "yearly" or "custom", in which case
+// CustomPeriod is the number of days of a period.
+type ProjectRecurrence struct {
+	Period           string `json:"period"`
+	CustomPeriod     *int   `json:"custom_period,omitempty"`
+	ProjectStartDate string `json:"project_start_date,omitempty"`
+
+	// set by the API on the periods of existing projects
+	ParameterStartDate string `json:"parameter_start_date,omitempty"`
+	ParameterEndDate   string `json:"parameter_end_date,omitempty"`
+	EstimatedSeconds   *int   `json:"estimated_seconds,omitempty"`
+}
+
+// ProjectOptions describes a project to create with CreateProjectWithOptions.
+// Options are set with chainable methods:
+//
+//	opts := toggl.NewProjectOptions("Website").WithClient(42).WithColor("#06aaf5").Private()
+type ProjectOptions struct {
+	Name           string             `json:"name"`
+	Active         bool               `json:"active"`
+	ClientID       *int               `json:"client_id,omitempty"`
+	Color          string             `json:"color,omitempty"`
+	IsPrivate      bool               `json:"is_private"`
+	Billable       *bool              `json:"billable,omitempty"`
+	EstimatedHours *int               `json:"estimated_hours,omitempty"`
+	Rate           *float64           `json:"rate,omitempty"`
+	Currency       *string            `json:"currency,omitempty"`
+	StartDate      string             `json:"start_date,omitempty"`
+	EndDate        *string            `json:"end_date,omitempty"`
+	Template       *bool              `json:"template,omitempty"`
+	TemplateID     *int               `json:"template_id,omitempty"`
+	AutoEstimates  *bool              `json:"auto_estimates,omitempty"`
+	Recurring      *bool              `json:"recurring,omitempty"`
+	Recurrence     *ProjectRecurrence `json:"recurring_parameters,omitempty"`
+}
+
+// NewProjectOptions returns the options of a new active, public project.
+func NewProjectOptions(name string) *ProjectOptions {
+	return &ProjectOptions{Name: name, Active: true}
+}
+
+// WithClient assigns the project to a client.
+func (o *ProjectOptions) WithClient(cid int) *ProjectOptions {
+	o.ClientID = &cid
+	return o
+}
+
+// WithColor sets the project color, as a hex code like "#06aaf5".
+func (o *ProjectOptions) WithColor(color string) *ProjectOptions {
+	o.Color = color
+	return o
+}
+
+// Private restricts the project to its members.
+func (o *ProjectOptions) Private() *ProjectOptions {
+	o.IsPrivate = true
+	return o
+}
+
+// Archived creates the project inactive.
+func (o *ProjectOptions) Archived() *ProjectOptions {
+	o.Active = false
+	return o
+}
+
+// WithBillable sets whether time tracked on the project is billable by
+// default. It is only meaningful for Toggl Pro accounts.
+func (o *ProjectOptions) WithBillable(billable bool) *ProjectOptions {
+	o.Billable = &billable
+	return o
+}
+
+// WithEstimate sets the estimated duration of the project in hours.
+func (o *ProjectOptions) WithEstimate(hours int) *ProjectOptions {
+	o.EstimatedHours = &hours
+	return o
+}
+
+// WithAutoEstimates sets whether the project estimate is computed from its
+// tasks estimates.
+func (o *ProjectOptions) WithAutoEstimates(auto bool) *ProjectOptions {
+	o.AutoEstimates = &auto
+	return o
+}
+
+// WithRate sets the hourly rate of the project, in the given currency.
+func (o *ProjectOptions) WithRate(rate float64, currency string) *ProjectOptions {
+	o.Rate = &rate
+	o.Currency = &currency
+	return o
+}
+
+// WithDates sets the project start date and, if not zero, its end date.
+func (o *ProjectOptions) WithDates(start, end time.Time) *ProjectOptions {
+	o.StartDate = start.Format("2006-01-02")
+	if !end.IsZero() {
+		endDate := end.Format("2006-01-02")
+		o.EndDate = &endDate
+	}
+	return o
+}
+
+// AsTemplate creates the project as a template for other projects.
+func (o *ProjectOptions) AsTemplate() *ProjectOptions {
+	template := true
+	o.Template = &template
+	return o
+}
+
+// FromTemplate creates the project from an existing template project.
+func (o *ProjectOptions) FromTemplate(templateID int) *ProjectOptions {
+	o.TemplateID = &templateID
+	return o
+}
+
+// Recurrent makes the project repeat every period from start: "daily",
+// "weekly", "monthly" or "yearly". Its estimate then applies to each period.
+func (o *ProjectOptions) Recurrent(period string, start time.Time) *ProjectOptions {
+	recurring := true
+	o.Recurring = &recurring
+	o.Recurrence = &ProjectRecurrence{Period: period, ProjectStartDate: start.Format("2006-01-02")}
+	return o
+}
+
+// RecurrentEvery makes the project repeat every given number of days from
+// start.
+func (o *ProjectOptions) RecurrentEvery(days int, start time.Time) *ProjectOptions {
+	o.Recurrent("custom", start)
+	o.Recurrence.CustomPeriod = &days
+	return o
+}
+
+// CopyOptions returns the options creating a copy of the project, with its
+// settings but without its client, which belongs to the project's workspace.
+func (p Project) CopyOptions() *ProjectOptions {
+	opts := NewProjectOptions(p.Name).WithColor(p.Color)
+	opts.Active = p.Active
+	opts.IsPrivate = p.IsPrivate
+	opts.Billable = p.Billable
+	opts.EstimatedHours = p.EstimatedHours
+	opts.AutoEstimates = p.AutoEstimates
+	opts.Rate = p.Rate
+	opts.Currency = p.Currency
+	opts.StartDate = p.StartDate
+	opts.EndDate = p.EndDate
+	if p.Recurring && len(p.Recurrences) > 0 {
+		r := p.Recurrences[0]
+		recurring := true
+		opts.Recurring = &recurring
+		opts.Recurrence = &ProjectRecurrence{Period: r.Period, CustomPeriod: r.CustomPeriod, ProjectStartDate: r.ProjectStartDate}
+	}
+	return opts
+}
+
+// CreateProjectWithOptions creates a new project with all the given settings.
+// Nil options are the zero options.
+func (session *Session) CreateProjectWithOptions(wid int, opts *ProjectOptions) (Project, error) {
+	if opts == nil {
+		opts = &ProjectOptions{}
+	}
+	session.logger.Debug("creating project", "projectName", opts.Name, "options", opts)
+
+	respData, err := session.post(TogglAPI, resource.GenerateResourceURL(resource.Projects, wid), opts)
+	if err != nil {
+		return Project{}, err
+	}
+
+	var project Project
+	err = json.Unmarshal(respData, &project)
+	if err != nil {
+		return Project{}, err
+	}
+
+	if project.Active {
+		session.cache.Update(resource.Projects, wid, project.ID, project)
+	}
+	return project, nil
+}
+
+// GetProjectUsers returns the members of a project, or of all the projects
+// of the workspace if pid is zero.
+func (session *Session) GetProjectUsers(wid int, pid int) ([]ProjectUser, error) {
+	session.logger.Debug("getting project users", "projectID", pid)
+
+	var params map[string]string
+	if pid != 0 {
+		params = map[string]string{"project_ids": fmt.Sprintf("%d", pid)}
+	}
+
+	data, err := session.get(TogglAPI, resource.GenerateResourceURL(resource.ProjectUsers, wid), params)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]ProjectUser, 0)
+	err = json.Unmarshal(data, &users)
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// AddProjectUser adds a member to a project.
+func (session *Session) AddProjectUser(user ProjectUser) (ProjectUser, error) {
+	session.logger.Debug("adding project user", "projectID", user.Pid, "userID", user.UserID)
+
+	respData, err := session.post(TogglAPI, resource.GenerateResourceURL(resource.ProjectUsers, user.Wid), user)
+	if err != nil {
+		return ProjectUser{}, err
+	}
+
+	var created ProjectUser
+	err = json.Unmarshal(respData, &created)
+	if err != nil {
+		return ProjectUser{}, err
+	}
+
+	return created, nil
+}
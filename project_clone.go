@@ -0,0 +1,247 @@
+package toggl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CloneProjectOptions tunes CloneProject.
+type CloneProjectOptions struct {
+	// Workspace is the workspace the clone is created in. It defaults to the
+	// workspace of the source project.
+	Workspace int
+	// ClientID assigns the clone to another client of the target workspace.
+	ClientID *int
+	// CopyClient assigns the clone to the client of the source project. When
+	// cloning to another workspace, a client with the same name is looked up
+	// there, and created if missing.
+	CopyClient bool
+	// SkipTasks and SkipMembers disable the copy of tasks and project members.
+	SkipTasks   bool
+	SkipMembers bool
+	// Tags are created in the target workspace if they do not exist yet.
+	Tags []string
+	// Resume continues a clone that partially failed, skipping the steps that
+	// already succeeded.
+	Resume *CloneProjectResult
+}
+
+// CloneProjectResult describes what CloneProject created. It is returned even
+// when the clone partially failed, and can be given back as
+// CloneProjectOptions.Resume to retry the failed steps.
+type CloneProjectResult struct {
+	Project Project
+	// Tasks maps the IDs of the source tasks to their copies.
+	Tasks map[int]Task
+	// Members maps the user IDs of the source project members to their new
+	// memberships.
+	Members map[int]ProjectUser
+	// Tags holds the tags found or created in the target workspace.
+	Tags map[string]Tag
+	// Failures lists the steps that failed.
+	Failures []CloneFailure
+}
+
+// CloneFailure is a step of CloneProject that failed.
+type CloneFailure struct {
+	// Kind is one of "task", "member" or "tag".
+	Kind string
+	// SourceID is the ID of the source task, or the user ID of the source
+	// member. It is zero for other kinds.
+	SourceID int
+	Name     string
+	Err      error
+}
+
+// CloneProjectError is returned by CloneProject when some steps failed.
+type CloneProjectError struct {
+	Failures []CloneFailure
+}
+
+func (e *CloneProjectError) Error() string {
+	msgs := make([]string, len(e.Failures))
+	for i, f := range e.Failures {
+		msgs[i] = fmt.Sprintf("%s %q: %v", f.Kind, f.Name, f.Err)
+	}
+	return fmt.Sprintf("project clone incomplete, %d failures: %s", len(e.Failures), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the errors of the failed steps.
+func (e *CloneProjectError) Unwrap() []error {
+	errs := make([]error, len(e.Failures))
+	for i, f := range e.Failures {
+		errs[i] = f.Err
+	}
+	return errs
+}
+
+// CloneProject creates a copy of a project named newName, with the same
+// settings, tasks and members. If the project itself cannot be created, an
+// error is returned right away; failures on tasks, members or tags are
+// collected in the result and reported by a *CloneProjectError.
+func (session *Session) CloneProject(src Project, newName string, opts CloneProjectOptions) (CloneProjectResult, error) {
+	result := CloneProjectResult{
+		Tasks:   make(map[int]Task),
+		Members: make(map[int]ProjectUser),
+		Tags:    make(map[string]Tag),
+	}
+	if opts.Resume != nil {
+		result.Project = opts.Resume.Project
+		for k, v := range opts.Resume.Tasks {
+			result.Tasks[k] = v
+		}
+		for k, v := range opts.Resume.Members {
+			result.Members[k] = v
+		}
+		for k, v := range opts.Resume.Tags {
+			result.Tags[k] = v
+		}
+	}
+
+	wid := opts.Workspace
+	if wid == 0 {
+		wid = src.Wid
+	}
+
+	session.logger.Debug("cloning project", "project", src, "name", newName, "workspaceID", wid)
+
+	if result.Project.ID == 0 {
+		projectOpts, err := session.cloneProjectOptions(src, newName, wid, opts)
+		if err != nil {
+			return result, fmt.Errorf("error resolving client: %v", err)
+		}
+
+		result.Project, err = session.CreateProjectWithOptions(wid, projectOpts)
+		if err != nil {
+			return result, fmt.Errorf("error creating project: %v", err)
+		}
+	}
+
+	if !opts.SkipTasks {
+		tasks, err := session.GetTasks(src.Wid)
+		if err != nil {
+			result.Failures = append(result.Failures, CloneFailure{Kind: "task", Name: src.Name, Err: err})
+		}
+		for _, t := range tasks {
+			if t.Pid != src.ID {
+				continue
+			}
+			if _, done := result.Tasks[t.ID]; done {
+				continue
+			}
+
+			task := Task{
+				Wid:              wid,
+				Pid:              result.Project.ID,
+				Name:             t.Name,
+				Active:           t.Active,
+				EstimatedSeconds: t.EstimatedSeconds,
+			}
+			created, err := session.CreateTask(task)
+			if err != nil {
+				result.Failures = append(result.Failures, CloneFailure{Kind: "task", SourceID: t.ID, Name: t.Name, Err: err})
+				continue
+			}
+			result.Tasks[t.ID] = created
+		}
+	}
+
+	if !opts.SkipMembers {
+		members, err := session.GetProjectUsers(src.Wid, src.ID)
+		if err != nil {
+			result.Failures = append(result.Failures, CloneFailure{Kind: "member", Name: src.Name, Err: err})
+		}
+		for _, m := range members {
+			if _, done := result.Members[m.UserID]; done {
+				continue
+			}
+
+			member := ProjectUser{
+				Wid:     wid,
+				Pid:     result.Project.ID,
+				UserID:  m.UserID,
+				Manager: m.Manager,
+				Rate:    m.Rate,
+			}
+			created, err := session.AddProjectUser(member)
+			if err != nil {
+				result.Failures = append(result.Failures, CloneFailure{Kind: "member", SourceID: m.UserID, Name: fmt.Sprintf("user %d", m.UserID), Err: err})
+				continue
+			}
+			result.Members[m.UserID] = created
+		}
+	}
+
+	for _, name := range opts.Tags {
+		if _, done := result.Tags[name]; done {
+			continue
+		}
+
+		tag, err := session.EnsureTag(wid, name)
+		if err != nil {
+			result.Failures = append(result.Failures, CloneFailure{Kind: "tag", Name: name, Err: err})
+			continue
+		}
+		result.Tags[name] = tag
+	}
+
+	if len(result.Failures) > 0 {
+		return result, &CloneProjectError{Failures: result.Failures}
+	}
+
+	return result, nil
+}
+
+// cloneProjectOptions returns the options creating a copy of src in the
+// workspace wid.
+func (session *Session) cloneProjectOptions(src Project, newName string, wid int, opts CloneProjectOptions) (*ProjectOptions, error) {
+	projectOpts := src.CopyOptions()
+	projectOpts.Name = newName
+	projectOpts.Active = true
+
+	switch {
+	case opts.ClientID != nil:
+		projectOpts.WithClient(*opts.ClientID)
+	case opts.CopyClient && src.Cid != nil && wid == src.Wid:
+		projectOpts.WithClient(*src.Cid)
+	case opts.CopyClient && src.Cid != nil:
+		client, err := session.targetClient(src.Wid, *src.Cid, wid)
+		if err != nil {
+			return nil, err
+		}
+		projectOpts.WithClient(client.ID)
+	}
+
+	return projectOpts, nil
+}
+
+// targetClient returns the client of the workspace wid named like the client
+// cid of the workspace srcWid, creating it if needed.
+func (session *Session) targetClient(srcWid int, cid int, wid int) (Client, error) {
+	clients, err := session.GetClients(srcWid)
+	if err != nil {
+		return Client{}, err
+	}
+
+	name := ""
+	for _, c := range clients {
+		if c.ID == cid {
+			name = c.Name
+		}
+	}
+	if name == "" {
+		return Client{}, fmt.Errorf("client %d: %w", cid, ErrNotFound)
+	}
+
+	targets, err := session.GetClients(wid)
+	if err != nil {
+		return Client{}, err
+	}
+	for _, c := range targets {
+		if c.Name == name {
+			return c, nil
+		}
+	}
+
+	return session.CreateClient(name, wid)
+}
@@ -0,0 +1,357 @@
+/*
+Package render writes Toggl values as tables, JSON, YAML, CSV or Go
+templates.
+
+	r, err := render.New("yaml")
+	...
+	err = r.Render(os.Stdout, entries)
+
+Tables and CSV are supported for time entries, expanded or not, detailed
+report entries, projects, clients, tags, reports and accounts. JSON, YAML
+and templates work with any value; JSON and YAML use the JSON field names of
+the toggl types.
+*/
+package render
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+
+	"github.com/leucos/go-toggl"
+	"gopkg.in/yaml.v3"
+)
+
+// Formats
+const (
+	Table    = "table"
+	JSON     = "json"
+	YAML     = "yaml"
+	CSV      = "csv"
+	Template = "template"
+)
+
+// Formats lists the supported output formats.
+var Formats = []string{Table, JSON, YAML, CSV, Template}
+
+// Names resolves the project and client IDs shown in tables. The IDs are
+// shown when a function is nil or returns an empty name.
+type Names struct {
+	Project func(id int) string
+	Client  func(id int) string
+}
+
+// Renderer writes values in an output format.
+type Renderer struct {
+	Format string
+
+	// Template is executed with the rendered value in the Template format.
+	Template *template.Template
+
+	// Names resolves IDs in tables and CSV.
+	Names Names
+
+	// Location is the time zone of the times in tables and CSV, time.Local
+	// if nil.
+	Location *time.Location
+}
+
+// New returns a renderer for an output specification: "table", "json",
+// "yaml", "csv" or "template=TEMPLATE", where TEMPLATE is a text/template
+// executed with the whole rendered value. Templates can use the duration
+// function formatting seconds as h:mm:ss, and the json function.
+func New(spec string) (*Renderer, error) {
+	format, text, _ := strings.Cut(spec, "=")
+	switch format {
+	case "":
+		return &Renderer{Format: Table}, nil
+	case Table, JSON, YAML, CSV:
+		if text != "" {
+			return nil, fmt.Errorf("output format %q takes no argument", format)
+		}
+		return &Renderer{Format: format}, nil
+	case Template:
+		tmpl, err := template.New("output").Funcs(funcs).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template: %v", err)
+		}
+		return &Renderer{Format: Template, Template: tmpl}, nil
+	default:
+		return nil, fmt.Errorf("unknown output format %q (use %s)", format, strings.Join(Formats, ", "))
+	}
+}
+
+var funcs = template.FuncMap{
+	"duration": Duration,
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// Render writes v to w.
+func (r *Renderer) Render(w io.Writer, v any) error {
+	switch r.Format {
+	case JSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case YAML:
+		return writeYAML(w, v)
+	case Template:
+		if r.Template == nil {
+			return fmt.Errorf("no template")
+		}
+		return r.Template.Execute(w, v)
+	case "", Table, CSV:
+		header, rows, err := r.table(v)
+		if err != nil {
+			return err
+		}
+		if r.Format == CSV {
+			return writeCSV(w, header, rows)
+		}
+		return writeTable(w, header, rows)
+	default:
+		return fmt.Errorf("unknown output format %q", r.Format)
+	}
+}
+
+// Duration formats seconds as h:mm:ss.
+func Duration(seconds int64) string {
+	sign := ""
+	if seconds < 0 {
+		sign, seconds = "-", -seconds
+	}
+	return fmt.Sprintf("%s%d:%02d:%02d", sign, seconds/3600, seconds/60%60, seconds%60)
+}
+
+func writeTable(w io.Writer, header []string, rows [][]string) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+func writeCSV(w io.Writer, header []string, rows [][]string) error {
+	cw := csv.NewWriter(w)
+	for i := range header {
+		header[i] = strings.ToLower(header[i])
+	}
+	cw.Write(header)
+	cw.WriteAll(rows)
+	return cw.Error()
+}
+
+// writeYAML writes v as YAML, keeping the JSON field names and order.
+func writeYAML(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	// JSON is YAML, but decoded nodes keep the JSON flow and quoting styles
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	resetStyle(&node)
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func resetStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		resetStyle(child)
+	}
+}
+
+// table returns the columns and rows of v.
+func (r *Renderer) table(v any) ([]string, [][]string, error) {
+	switch v := v.(type) {
+	case toggl.TimeEntry:
+		return r.table([]toggl.TimeEntry{v})
+	case []toggl.TimeEntry:
+		rows := make([][]string, 0, len(v))
+		for _, entry := range v {
+			duration := entry.Duration
+			if entry.IsRunning() {
+				duration = int64(time.Since(entry.StartTime()) / time.Second)
+			}
+			project := entry.ProjectName
+			if project == "" && entry.Pid != nil {
+				project = r.project(*entry.Pid)
+			}
+			rows = append(rows, []string{
+				strconv.Itoa(entry.ID),
+				r.time(entry.Start),
+				Duration(duration),
+				project,
+				entry.Description,
+				strings.Join(entry.Tags, ","),
+			})
+		}
+		return []string{"ID", "START", "DURATION", "PROJECT", "DESCRIPTION", "TAGS"}, rows, nil
+
+	case toggl.ExpandedTimeEntry:
+		return r.table([]toggl.ExpandedTimeEntry{v})
+	case []toggl.ExpandedTimeEntry:
+		entries := make([]toggl.TimeEntry, len(v))
+		for i, entry := range v {
+			entries[i] = entry.TimeEntry
+			if entry.Project != nil {
+				entries[i].ProjectName = entry.Project.Name
+			}
+		}
+		return r.table(entries)
+
+	case toggl.DetailedReport:
+		return r.table(v.Data)
+	case []toggl.DetailedTimeEntry:
+		rows := make([][]string, 0, len(v))
+		for _, entry := range v {
+			var start *time.Time
+			if entry.Start != nil {
+				start = &entry.Start.Time
+			}
+			rows = append(rows, []string{
+				strconv.Itoa(entry.ID),
+				r.time(start),
+				Duration(entry.Duration / 1000),
+				entry.Project,
+				entry.Client,
+				entry.Description,
+				strings.Join(entry.Tags, ","),
+			})
+		}
+		return []string{"ID", "START", "DURATION", "PROJECT", "CLIENT", "DESCRIPTION", "TAGS"}, rows, nil
+
+	case toggl.SummaryReport:
+		rows := make([][]string, 0, len(v.Data)+1)
+		for _, d := range v.Data {
+			project := d.Title.Project
+			if project == "" {
+				project = "(no project)"
+			}
+			rows = append(rows, []string{project, d.Title.Client, Duration(int64(d.Time / 1000))})
+		}
+		rows = append(rows, []string{"TOTAL", "", Duration(int64(v.TotalGrand / 1000))})
+		return []string{"PROJECT", "CLIENT", "TIME"}, rows, nil
+
+	case toggl.Project:
+		return r.table([]toggl.Project{v})
+	case []toggl.Project:
+		rows := make([][]string, 0, len(v))
+		for _, p := range v {
+			client := ""
+			if p.Cid != nil {
+				client = r.client(*p.Cid)
+			}
+			rows = append(rows, []string{strconv.Itoa(p.ID), p.Name, client, strconv.FormatBool(p.Active)})
+		}
+		return []string{"ID", "NAME", "CLIENT", "ACTIVE"}, rows, nil
+
+	case toggl.Client:
+		return r.table([]toggl.Client{v})
+	case []toggl.Client:
+		rows := make([][]string, 0, len(v))
+		for _, c := range v {
+			rows = append(rows, []string{strconv.Itoa(c.ID), c.Name})
+		}
+		return []string{"ID", "NAME"}, rows, nil
+
+	case toggl.Tag:
+		return r.table([]toggl.Tag{v})
+	case []toggl.Tag:
+		rows := make([][]string, 0, len(v))
+		for _, t := range v {
+			rows = append(rows, []string{strconv.Itoa(t.ID), t.Name})
+		}
+		return []string{"ID", "NAME"}, rows, nil
+
+	case toggl.Account:
+		rows := make([][]string, 0, len(v.Workspaces))
+		for _, ws := range v.Workspaces {
+			def := ""
+			if ws.ID == v.DefaultWid {
+				def = "*"
+			}
+			rows = append(rows, []string{strconv.Itoa(ws.ID), ws.Name, def})
+		}
+		return []string{"WORKSPACE", "NAME", "DEFAULT"}, rows, nil
+	}
+
+	return nil, nil, fmt.Errorf("cannot render %T as a table", v)
+}
+
+func (r *Renderer) project(id int) string {
+	if r.Names.Project != nil {
+		if name := r.Names.Project(id); name != "" {
+			return name
+		}
+	}
+	return strconv.Itoa(id)
+}
+
+func (r *Renderer) client(id int) string {
+	if r.Names.Client != nil {
+		if name := r.Names.Client(id); name != "" {
+			return name
+		}
+	}
+	return strconv.Itoa(id)
+}
+
+func (r *Renderer) time(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	loc := r.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	return t.In(loc).Format("2006-01-02 15:04")
+}
+
+// SessionNames returns names looked up with session in the workspace wid.
+// Projects and clients are only fetched when a name is needed.
+func SessionNames(session *toggl.Session, wid int) Names {
+	var projects, clients map[int]string
+
+	return Names{
+		Project: func(id int) string {
+			if projects == nil {
+				projects = map[int]string{}
+				list, _ := session.GetProjects(wid, toggl.AllProjects)
+				for _, p := range list {
+					projects[p.ID] = p.Name
+				}
+			}
+			return projects[id]
+		},
+		Client: func(id int) string {
+			if clients == nil {
+				clients = map[int]string{}
+				list, _ := session.GetClients(wid)
+				for _, c := range list {
+					clients[c.ID] = c.Name
+				}
+			}
+			return clients[id]
+		},
+	}
+}
@@ -0,0 +1,226 @@
+package toggl
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ErrNotFound is returned by the Find* helpers when nothing matches a name.
+var ErrNotFound = errors.New("not found")
+
+// Candidate is a resource that matched an ambiguous name.
+type Candidate struct {
+	ID   int
+	Name string
+}
+
+// AmbiguousMatchError is returned by the Find* helpers when a name matches
+// several resources equally well.
+type AmbiguousMatchError struct {
+	Kind       string
+	Query      string
+	Candidates []Candidate
+}
+
+func (e *AmbiguousMatchError) Error() string {
+	names := make([]string, len(e.Candidates))
+	for i, c := range e.Candidates {
+		names[i] = fmt.Sprintf("%q (%d)", c.Name, c.ID)
+	}
+	return fmt.Sprintf("%s %q is ambiguous, candidates are: %s", e.Kind, e.Query, strings.Join(names, ", "))
+}
+
+// FindClientByName returns the client of a workspace matching name. Exact
+// matches are preferred over case-insensitive ones, which are preferred over
+// fuzzy ones.
+func (session *Session) FindClientByName(wid int, name string) (Client, error) {
+	clients, err := session.GetClients(wid)
+	if err != nil {
+		return Client{}, err
+	}
+
+	matches := matchByName(clients, name, func(c Client) string { return c.Name })
+	switch len(matches) {
+	case 0:
+		return Client{}, fmt.Errorf("client %q: %w", name, ErrNotFound)
+	case 1:
+		return matches[0], nil
+	}
+
+	ambiguous := &AmbiguousMatchError{Kind: "client", Query: name}
+	for _, c := range matches {
+		ambiguous.Candidates = append(ambiguous.Candidates, Candidate{ID: c.ID, Name: c.Name})
+	}
+	return Client{}, ambiguous
+}
+
+// FindProjectByName returns the project of a workspace matching name. The name
+// can be prefixed with a client name, as in "Acme / Website redesign", to
+// disambiguate projects with the same name. Active projects are preferred
+// over archived ones.
+func (session *Session) FindProjectByName(wid int, name string) (Project, error) {
+	projects, err := session.GetProjects(wid)
+	if err != nil {
+		return Project{}, err
+	}
+
+	matches := matchByName(projects, name, func(p Project) string { return p.Name })
+	if len(matches) == 0 {
+		if clientName, projectName, ok := strings.Cut(name, "/"); ok {
+			return session.FindClientProject(wid, strings.TrimSpace(clientName), strings.TrimSpace(projectName))
+		}
+	}
+
+	return session.pickProject(wid, name, matches)
+}
+
+// FindClientProject returns the project matching name among the projects of
+// the client matching clientName.
+func (session *Session) FindClientProject(wid int, clientName, name string) (Project, error) {
+	client, err := session.FindClientByName(wid, clientName)
+	if err != nil {
+		return Project{}, err
+	}
+
+	projects, err := session.GetProjects(wid)
+	if err != nil {
+		return Project{}, err
+	}
+
+	var owned []Project
+	for _, p := range projects {
+		if p.Cid != nil && *p.Cid == client.ID {
+			owned = append(owned, p)
+		}
+	}
+
+	matches := matchByName(owned, name, func(p Project) string { return p.Name })
+	return session.pickProject(wid, client.Name+" / "+name, matches)
+}
+
+func (session *Session) pickProject(wid int, query string, matches []Project) (Project, error) {
+	var active []Project
+	for _, p := range matches {
+		if p.IsActive() {
+			active = append(active, p)
+		}
+	}
+	if len(active) > 0 {
+		matches = active
+	}
+
+	switch len(matches) {
+	case 0:
+		return Project{}, fmt.Errorf("project %q: %w", query, ErrNotFound)
+	case 1:
+		return matches[0], nil
+	}
+
+	// label candidates with their client so that the caller can pick one
+	clientNames := map[int]string{}
+	if clients, err := session.GetClients(wid); err == nil {
+		for _, c := range clients {
+			clientNames[c.ID] = c.Name
+		}
+	}
+
+	ambiguous := &AmbiguousMatchError{Kind: "project", Query: query}
+	for _, p := range matches {
+		label := p.Name
+		if p.Cid != nil && clientNames[*p.Cid] != "" {
+			label = clientNames[*p.Cid] + " / " + p.Name
+		}
+		ambiguous.Candidates = append(ambiguous.Candidates, Candidate{ID: p.ID, Name: label})
+	}
+	return Project{}, ambiguous
+}
+
+// FindTask returns the task matching name in a workspace. If pid is not zero,
+// only the tasks of that project are considered.
+func (session *Session) FindTask(wid int, pid int, name string) (Task, error) {
+	tasks, err := session.GetTasks(wid)
+	if err != nil {
+		return Task{}, err
+	}
+
+	if pid != 0 {
+		var owned []Task
+		for _, t := range tasks {
+			if t.Pid == pid {
+				owned = append(owned, t)
+			}
+		}
+		tasks = owned
+	}
+
+	matches := matchByName(tasks, name, func(t Task) string { return t.Name })
+	switch len(matches) {
+	case 0:
+		return Task{}, fmt.Errorf("task %q: %w", name, ErrNotFound)
+	case 1:
+		return matches[0], nil
+	}
+
+	ambiguous := &AmbiguousMatchError{Kind: "task", Query: name}
+	for _, t := range matches {
+		ambiguous.Candidates = append(ambiguous.Candidates, Candidate{ID: t.ID, Name: t.Name})
+	}
+	return Task{}, ambiguous
+}
+
+// matchByName returns the items whose name matches query, using the
+// strictest rule that matches anything: exact, case-insensitive, substring of
+// the normalized name, and finally subsequence of the normalized name.
+func matchByName[T any](items []T, query string, name func(T) string) []T {
+	rules := []func(string) bool{
+		func(n string) bool { return n == query },
+		func(n string) bool { return strings.EqualFold(n, query) },
+		func(n string) bool { return strings.Contains(normalizeName(n), normalizeName(query)) },
+		func(n string) bool { return isSubsequence(normalizeName(query), normalizeName(n)) },
+	}
+
+	if normalizeName(query) == "" {
+		rules = rules[:2]
+	}
+
+	for _, rule := range rules {
+		var matches []T
+		for _, item := range items {
+			if rule(name(item)) {
+				matches = append(matches, item)
+			}
+		}
+		if len(matches) > 0 {
+			sort.SliceStable(matches, func(i, j int) bool { return name(matches[i]) < name(matches[j]) })
+			return matches
+		}
+	}
+
+	return nil
+}
+
+// normalizeName lowercases s and drops everything but letters and digits.
+func normalizeName(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return b.String()
+}
+
+// isSubsequence reports whether the runes of sub appear in s in order.
+func isSubsequence(sub, s string) bool {
+	rs := []rune(sub)
+	i := 0
+	for _, r := range s {
+		if i < len(rs) && r == rs[i] {
+			i++
+		}
+	}
+	return i == len(rs)
+}
@@ -0,0 +1,94 @@
+package toggl
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestMatchByName(t *testing.T) {
+	names := []string{"Website", "website", "Web site redesign", "Mobile app", "Wiki", "Ops / On-call"}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		// exact matches win over case-insensitive ones
+		{"Website", []string{"Website"}},
+		{"website", []string{"website"}},
+		// case-insensitive matches are ambiguous here
+		{"WEBSITE", []string{"Website", "website"}},
+		// then substrings of the normalized names
+		{"redesign", []string{"Web site redesign"}},
+		{"site", []string{"Web site redesign", "Website", "website"}},
+		{"mobile-app", []string{"Mobile app"}},
+		{"on call", []string{"Ops / On-call"}},
+		// then subsequences
+		{"mbl", []string{"Mobile app"}},
+		{"wk", []string{"Wiki"}},
+		{"zzz", nil},
+		// names without letters or digits only match exactly
+		{"/", nil},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		got := matchByName(names, tt.query, func(n string) string { return n })
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("matchByName(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct{ name, want string }{
+		{"Web Site", "website"},
+		{"Ops / On-call", "opsoncall"},
+		{"Réunion 2026", "réunion2026"},
+		{"ÅSA", "åsa"},
+		{"--", ""},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeName(tt.name); got != tt.want {
+			t.Errorf("normalizeName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsSubsequence(t *testing.T) {
+	tests := []struct {
+		sub, s string
+		want   bool
+	}{
+		{"", "anything", true},
+		{"wsr", "websiteredesign", true},
+		{"rw", "websiteredesign", false},
+		{"éu", "réunion", true},
+		{"abc", "ab", false},
+	}
+
+	for _, tt := range tests {
+		if got := isSubsequence(tt.sub, tt.s); got != tt.want {
+			t.Errorf("isSubsequence(%q, %q) = %v, want %v", tt.sub, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestAmbiguousMatchError(t *testing.T) {
+	var err error = &AmbiguousMatchError{
+		Kind:       "project",
+		Query:      "web",
+		Candidates: []Candidate{{ID: 1, Name: "Acme / Website"}, {ID: 2, Name: "Website"}},
+	}
+
+	want := `project "web" is ambiguous, candidates are: "Acme / Website" (1), "Website" (2)`
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+
+	var ambiguous *AmbiguousMatchError
+	if !errors.As(err, &ambiguous) || len(ambiguous.Candidates) != 2 {
+		t.Errorf("errors.As(%v) failed", err)
+	}
+}
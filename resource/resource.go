@@ -9,13 +9,19 @@ const (
 	Projects
 	Tags
 	TimeEntries
+	Tasks
+	ProjectUsers
+	Users
 )
 
 var TypeMap = map[Type]string{
-	Clients:     "clients",
-	Projects:    "projects",
-	Tags:        "tags",
-	TimeEntries: "time_entries",
+	Clients:      "clients",
+	Projects:     "projects",
+	ProjectUsers: "project_users",
+	Tags:         "tags",
+	Tasks:        "tasks",
+	TimeEntries:  "time_entries",
+	Users:        "users",
 }
 
 func (r Type) String() string {
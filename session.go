@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/go-retryablehttp"
@@ -23,6 +24,10 @@ type Session struct {
 	password string
 	logger   *slog.Logger
 	cache    cache.ResourcesCache
+	autoWarm bool
+	offline  *offlineJournal
+	watcher  *currentWatcher
+	locale   *locale
 }
 
 const (
@@ -37,6 +42,8 @@ func OpenSession(apiToken string) Session {
 	}
 
 	s.cache = cache.New(DefaultTTL)
+	s.watcher = newCurrentWatcher()
+	s.locale = newLocale()
 	return s
 }
 
@@ -64,6 +71,9 @@ func NewSession(username, password string) (*Session, error) {
 	session.APIToken = account.APIToken
 
 	session.cache = cache.New(DefaultTTL)
+	session.watcher = newCurrentWatcher()
+	session.locale = newLocale()
+	session.setAccountLocale(account)
 
 	return &session, nil
 }
@@ -83,9 +93,46 @@ func (session *Session) EnableLog(l *slog.Logger) {
 	session.logger = slog.New(slog.NewTextHandler(os.Stderr, nil))
 }
 
+// EnableAutoWarm makes GetAccount seed the resource caches with the related
+// data it receives
+func (session *Session) EnableAutoWarm() {
+	session.autoWarm = true
+}
+
+// DisableAutoWarm stops GetAccount from seeding the resource caches
+func (session *Session) DisableAutoWarm() {
+	session.autoWarm = false
+}
+
 // GetAccount returns a user's account information, including a list of active
-// projects and timers.
+// projects and timers. If auto warm-up is enabled, the returned clients,
+// projects, tasks and tags are also stored in the session cache.
 func (session *Session) GetAccount() (Account, error) {
+	account, err := session.getAccount()
+	if err != nil {
+		return Account{}, err
+	}
+
+	if session.autoWarm {
+		session.warmCache(account)
+	}
+
+	return account, nil
+}
+
+// Warm fetches the account with its related data in a single request and
+// seeds the clients, projects, tasks and tags caches with it.
+func (session *Session) Warm() error {
+	account, err := session.getAccount()
+	if err != nil {
+		return err
+	}
+
+	session.warmCache(account)
+	return nil
+}
+
+func (session *Session) getAccount() (Account, error) {
 	params := map[string]string{"with_related_data": "true"}
 	data, err := session.get(TogglAPI, "/me", params)
 	if err != nil {
@@ -97,10 +144,43 @@ func (session *Session) GetAccount() (Account, error) {
 	if err != nil {
 		return Account{}, fmt.Errorf("error decoding account data: %v", err)
 	}
+	session.setAccountLocale(account)
 
 	return account, nil
 }
 
+// warmCache replaces the cached clients, projects, tasks and tags with the
+// ones found in account. Every workspace of the account gets an entry, even
+// when it has no resources of a given type, so that later lookups are served
+// from the cache.
+func (session *Session) warmCache(account Account) {
+	session.logger.Debug("warming cache", "workspaces", len(account.Workspaces))
+
+	for _, rt := range []resource.Type{resource.Clients, resource.Projects, resource.Tasks, resource.Tags} {
+		session.cache.Clear(rt)
+		session.cache.Touch(rt)
+		for _, w := range account.Workspaces {
+			session.cache.Init(rt, w.ID)
+		}
+	}
+
+	for _, c := range account.Clients {
+		session.cache.Set(resource.Clients, c.Wid, c.ID, c)
+	}
+	for _, p := range account.Projects {
+		// the projects cache only holds active projects
+		if p.Active {
+			session.cache.Set(resource.Projects, p.Wid, p.ID, p)
+		}
+	}
+	for _, t := range account.Tasks {
+		session.cache.Set(resource.Tasks, t.Wid, t.ID, t)
+	}
+	for _, t := range account.Tags {
+		session.cache.Set(resource.Tags, t.Wid, t.ID, t)
+	}
+}
+
 // GetSummaryReport retrieves a summary report using Toggle's reporting API.
 func (session *Session) GetSummaryReport(workspace int, since, until string) (SummaryReport, error) {
 	params := map[string]string{
@@ -123,6 +203,12 @@ func (session *Session) GetSummaryReport(workspace int, since, until string) (Su
 
 // GetDetailedReport retrieves a detailed report using Toggle's reporting API.
 func (session *Session) GetDetailedReport(workspace int, since, until string, page int) (DetailedReport, error) {
+	return session.getDetailedReport(workspace, since, until, page, nil)
+}
+
+// getDetailedReport retrieves a detailed report page, narrowed down by the
+// given extra report filters (e.g. "tag_ids" or "project_ids").
+func (session *Session) getDetailedReport(workspace int, since, until string, page int, filters map[string]string) (DetailedReport, error) {
 	params := map[string]string{
 		"user_agent":   "jc-toggl",
 		"since":        since,
@@ -130,6 +216,9 @@ func (session *Session) GetDetailedReport(workspace int, since, until string, pa
 		"page":         fmt.Sprintf("%d", page),
 		"rounding":     "on",
 		"workspace_id": fmt.Sprintf("%d", workspace)}
+	for key, value := range filters {
+		params[key] = value
+	}
 	data, err := session.get(ReportsAPI, "/details", params)
 	if err != nil {
 		return DetailedReport{}, err
@@ -141,9 +230,85 @@ func (session *Session) GetDetailedReport(workspace int, since, until string, pa
 	return report, err
 }
 
+// reportWindow is the longest period the reports API accepts in one request.
+const reportWindow = 365 * 24 * time.Hour
+
+// forEachDetailedTimeEntry calls fn for every entry of the detailed reports
+// between since and until, splitting the range into periods the reports API
+// accepts and walking through all the pages.
+func (session *Session) forEachDetailedTimeEntry(wid int, since, until time.Time, filters map[string]string, fn func(DetailedTimeEntry) error) error {
+	for start := since; start.Before(until); start = start.Add(reportWindow) {
+		end := start.Add(reportWindow - 24*time.Hour)
+		if end.After(until) {
+			end = until
+		}
+
+		for page, seen := 1, 0; ; page++ {
+			report, err := session.getDetailedReport(wid, session.reportDate(start), session.reportDate(end), page, filters)
+			if err != nil {
+				return err
+			}
+
+			for _, entry := range report.Data {
+				if err := fn(entry); err != nil {
+					return err
+				}
+			}
+
+			seen += len(report.Data)
+			if len(report.Data) == 0 || seen >= report.TotalCount {
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// detailedReportTotals returns the number of entries of the detailed reports
+// between since and until, and their total duration.
+func (session *Session) detailedReportTotals(wid int, since, until time.Time, filters map[string]string) (int, time.Duration, error) {
+	count, total := 0, time.Duration(0)
+	for start := since; start.Before(until); start = start.Add(reportWindow) {
+		end := start.Add(reportWindow - 24*time.Hour)
+		if end.After(until) {
+			end = until
+		}
+
+		report, err := session.getDetailedReport(wid, session.reportDate(start), session.reportDate(end), 1, filters)
+		if err != nil {
+			return 0, 0, err
+		}
+		count += report.TotalCount
+		total += time.Duration(report.TotalGrand) * time.Millisecond
+	}
+
+	return count, total, nil
+}
+
+// ForEachDetailedTimeEntry calls fn for every time entry tracked in a
+// workspace between since and until, as listed by the detailed reports. It
+// stops at the first error returned by fn.
+func (session *Session) ForEachDetailedTimeEntry(wid int, since, until time.Time, fn func(DetailedTimeEntry) error) error {
+	return session.forEachDetailedTimeEntry(wid, since, until, nil, fn)
+}
+
+// ForEachUserDetailedTimeEntry calls fn for every time entry tracked by a
+// user in a workspace between since and until, as ForEachDetailedTimeEntry.
+func (session *Session) ForEachUserDetailedTimeEntry(wid int, uid int, since, until time.Time, fn func(DetailedTimeEntry) error) error {
+	return session.forEachDetailedTimeEntry(wid, since, until, map[string]string{"user_ids": fmt.Sprintf("%d", uid)}, fn)
+}
+
 // startTimeEntry unified way how to start new entries. Eventually it should replace StartTimeEntry and
 // StartTimeEntryForProject functions, which are for time-being kept for compatibility.
 func (session *Session) startTimeEntry(timeEntry timeEntryCreate) (TimeEntry, error) {
+	return session.offlineMutation(
+		OfflineMutation{Kind: OfflineCreate, Entry: timeEntry.asTimeEntry()},
+		func() (TimeEntry, error) { return session.postTimeEntry(timeEntry) },
+	)
+}
+
+func (session *Session) postTimeEntry(timeEntry timeEntryCreate) (TimeEntry, error) {
 	return handleTimeEntryResponse(
 		session.post(TogglAPI, resource.GenerateResourceURL(resource.TimeEntries, timeEntry.WorkspaceId), timeEntry),
 	)
@@ -167,6 +332,111 @@ func (session *Session) StartTimeEntryForProject(description string, wid int, pr
 	return session.startTimeEntry(entry)
 }
 
+// CreateTimeEntry creates a time entry with the description, metadata, start
+// and duration of the given one. A negative duration creates a running entry.
+func (session *Session) CreateTimeEntry(timer TimeEntry) (TimeEntry, error) {
+	session.logger.Debug("creating timer", "timer", timer)
+	return session.startTimeEntry(newCreateEntryRequestData(timer))
+}
+
+// GetTimeEntry returns a time entry of the user by ID
+func (session *Session) GetTimeEntry(id int) (TimeEntry, error) {
+	return handleTimeEntryResponse(
+		session.get(TogglAPI, resource.GenerateUserResourceURL(resource.TimeEntries)+fmt.Sprintf("/%d", id), map[string]string{"meta": "true"}),
+	)
+}
+
+// GetExpandedTimeEntry returns a time entry of the user by ID, with its
+// project, client and task.
+func (session *Session) GetExpandedTimeEntry(id int) (ExpandedTimeEntry, error) {
+	timer, err := session.GetTimeEntry(id)
+	if err != nil {
+		return ExpandedTimeEntry{}, err
+	}
+	return session.ExpandTimeEntry(timer)
+}
+
+// ExpandTimeEntry returns a time entry with its project, client and task.
+func (session *Session) ExpandTimeEntry(timer TimeEntry) (ExpandedTimeEntry, error) {
+	expanded, err := session.ExpandTimeEntries([]TimeEntry{timer})
+	if err != nil {
+		return ExpandedTimeEntry{}, err
+	}
+	return expanded[0], nil
+}
+
+// ExpandTimeEntries returns time entries with their projects, clients and
+// tasks, looked up in the cached resources of their workspaces.
+func (session *Session) ExpandTimeEntries(timers []TimeEntry) ([]ExpandedTimeEntry, error) {
+	projects := map[int]map[int]Project{}
+	clients := map[int]map[int]Client{}
+	tasks := map[int]map[int]Task{}
+
+	expanded := make([]ExpandedTimeEntry, len(timers))
+	for i, timer := range timers {
+		expanded[i].TimeEntry = timer
+		if timer.Pid == nil {
+			continue
+		}
+
+		if projects[timer.Wid] == nil {
+			list, err := session.GetProjects(timer.Wid)
+			if err != nil {
+				return nil, fmt.Errorf("error getting projects: %v", err)
+			}
+			projects[timer.Wid] = map[int]Project{}
+			for _, p := range list {
+				projects[timer.Wid][p.ID] = p
+			}
+		}
+		project, ok := projects[timer.Wid][*timer.Pid]
+		if !ok {
+			// archived projects are not cached
+			var err error
+			project, err = session.GetProject(*timer.Pid, timer.Wid)
+			if err != nil {
+				return nil, fmt.Errorf("error getting project %d: %v", *timer.Pid, err)
+			}
+			projects[timer.Wid][project.ID] = project
+		}
+		expanded[i].Project = &project
+
+		if project.Cid != nil {
+			if clients[timer.Wid] == nil {
+				list, err := session.GetClients(timer.Wid)
+				if err != nil {
+					return nil, fmt.Errorf("error getting clients: %v", err)
+				}
+				clients[timer.Wid] = map[int]Client{}
+				for _, c := range list {
+					clients[timer.Wid][c.ID] = c
+				}
+			}
+			if client, ok := clients[timer.Wid][*project.Cid]; ok {
+				expanded[i].Client = &client
+			}
+		}
+
+		if timer.Tid != nil {
+			if tasks[timer.Wid] == nil {
+				list, err := session.GetTasks(timer.Wid)
+				if err != nil {
+					return nil, fmt.Errorf("error getting tasks: %v", err)
+				}
+				tasks[timer.Wid] = map[int]Task{}
+				for _, t := range list {
+					tasks[timer.Wid][t.ID] = t
+				}
+			}
+			if task, ok := tasks[timer.Wid][*timer.Tid]; ok {
+				expanded[i].Task = &task
+			}
+		}
+	}
+
+	return expanded, nil
+}
+
 // GetCurrentTimeEntry returns the current time entry, that's running
 func (session *Session) GetCurrentTimeEntry() (TimeEntry, error) {
 	return handleTimeEntryResponse(
@@ -182,6 +452,7 @@ func (session *Session) GetTimeEntries(startDate, endDate time.Time) ([]TimeEntr
 		map[string]string{
 			"start_date": startDate.Format(time.RFC3339),
 			"end_date":   endDate.Format(time.RFC3339),
+			"meta":       "true",
 		},
 	)
 
@@ -198,11 +469,40 @@ func (session *Session) GetTimeEntries(startDate, endDate time.Time) ([]TimeEntr
 	return results, nil
 }
 
-// UpdateTimeEntry changes information about an existing time entry.
+// GetTimeEntriesSince returns the time entries of the user created, updated or
+// deleted since a given time. Deleted entries have ServerDeletedAt set.
+func (session *Session) GetTimeEntriesSince(since time.Time) ([]TimeEntry, error) {
+	data, err := session.get(
+		TogglAPI,
+		resource.GenerateUserResourceURL(resource.TimeEntries),
+		map[string]string{"since": fmt.Sprintf("%d", since.Unix())},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []TimeEntry
+	err = json.Unmarshal(data, &results)
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// UpdateTimeEntry changes information about an existing time entry. Its
+// TagIDs are ignored unless they differ from the ones it was decoded with.
 func (session *Session) UpdateTimeEntry(timer TimeEntry) (TimeEntry, error) {
 	session.logger.Debug("updating timer", "timer", timer)
+	return session.offlineMutation(
+		OfflineMutation{Kind: OfflineUpdate, Entry: timer},
+		func() (TimeEntry, error) { return session.putTimeEntry(timer) },
+	)
+}
+
+func (session *Session) putTimeEntry(timer TimeEntry) (TimeEntry, error) {
 	return handleTimeEntryResponse(
-		session.put(TogglAPI, resource.GenerateResourceURLWithID(resource.TimeEntries, timer.Wid, timer.ID), timer),
+		session.put(TogglAPI, resource.GenerateResourceURLWithID(resource.TimeEntries, timer.Wid, timer.ID), timer.forUpdate()),
 	)
 }
 
@@ -212,8 +512,7 @@ func (session *Session) UpdateTimeEntry(timer TimeEntry) (TimeEntry, error) {
 // the existing one.
 func (session *Session) ContinueTimeEntry(timer TimeEntry, duronly bool) (TimeEntry, error) {
 	session.logger.Debug("continuing timer", "timer", timer)
-	if duronly &&
-		time.Now().Local().Format("2006-01-02") == timer.Start.Local().Format("2006-01-02") {
+	if duronly && session.SameDay(time.Now(), timer.StartTime()) {
 		// If we're doing a duration-only continuation for a timer today, then basically only unstop the timer
 		return session.UnstopTimeEntry(timer)
 	} else {
@@ -250,10 +549,18 @@ func (session *Session) UnstopTimeEntry(timer TimeEntry) (TimeEntry, error) {
 // StopTimeEntry stops a running time entry.
 func (session *Session) StopTimeEntry(timer TimeEntry) (TimeEntry, error) {
 	session.logger.Debug("stopping timer", "timer", timer)
+	return session.offlineMutation(
+		OfflineMutation{Kind: OfflineStop, Entry: timer},
+		func() (TimeEntry, error) { return session.stopTimeEntry(timer) },
+	)
+}
+
+func (session *Session) stopTimeEntry(timer TimeEntry) (TimeEntry, error) {
 	return handleTimeEntryResponse(
 		session.patch(
 			TogglAPI,
 			resource.GenerateResourceURLWithID(resource.TimeEntries, timer.Wid, timer.ID)+"/stop",
+			nil,
 		),
 	)
 }
@@ -268,6 +575,13 @@ func (session *Session) AddRemoveTag(timeEntryId int, tag string, add bool, wid
 
 	session.logger.Debug("changing tag in time entry", "action", action, "tag", tag, "timeEntryID", timeEntryId)
 
+	return session.offlineMutation(
+		OfflineMutation{Kind: OfflineTag, Entry: TimeEntry{ID: timeEntryId, Wid: wid}, Tag: tag, AddTag: add},
+		func() (TimeEntry, error) { return session.changeTag(timeEntryId, tag, action, wid) },
+	)
+}
+
+func (session *Session) changeTag(timeEntryId int, tag string, action string, wid int) (TimeEntry, error) {
 	data := map[string]interface{}{
 		"tags":       []string{tag},
 		"tag_action": action,
@@ -278,16 +592,86 @@ func (session *Session) AddRemoveTag(timeEntryId int, tag string, add bool, wid
 	)
 }
 
-// DeleteTimeEntry deletes a time entry.
+// timeEntryPatch is a JSON patch operation applied to time entries in bulk.
+type timeEntryPatch struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// maxBulkTimeEntries is the maximum number of time entries the API lets us
+// patch in a single request.
+const maxBulkTimeEntries = 100
+
+// patchTimeEntries applies the same patch operations to the given time
+// entries, in batches the API accepts.
+func (session *Session) patchTimeEntries(wid int, ids []int, ops []timeEntryPatch) error {
+	for len(ids) > 0 {
+		n := min(len(ids), maxBulkTimeEntries)
+		batch := make([]string, n)
+		for i, id := range ids[:n] {
+			batch[i] = fmt.Sprintf("%d", id)
+		}
+		ids = ids[n:]
+
+		data, err := session.patch(
+			TogglAPI,
+			resource.GenerateResourceURL(resource.TimeEntries, wid)+"/"+strings.Join(batch, ","),
+			ops,
+		)
+		if err != nil {
+			return err
+		}
+
+		var result struct {
+			Failure []struct {
+				ID      int    `json:"id"`
+				Message string `json:"message"`
+			} `json:"failure"`
+		}
+		err = json.Unmarshal(data, &result)
+		if err != nil {
+			return err
+		}
+		if len(result.Failure) > 0 {
+			return fmt.Errorf("time entry %d not updated: %s (%d failures)",
+				result.Failure[0].ID, result.Failure[0].Message, len(result.Failure))
+		}
+	}
+
+	return nil
+}
+
+// DeleteTimeEntry deletes a time entry. A deletion journaled in offline mode
+// returns no data.
 func (session *Session) DeleteTimeEntry(timer TimeEntry) ([]byte, error) {
 	session.logger.Debug("deleting timer", "timer", timer)
+
+	var data []byte
+	_, err := session.offlineMutation(
+		OfflineMutation{Kind: OfflineDelete, Entry: timer},
+		func() (TimeEntry, error) {
+			var err error
+			data, err = session.deleteTimeEntry(timer)
+			return TimeEntry{}, err
+		},
+	)
+	return data, err
+}
+
+func (session *Session) deleteTimeEntry(timer TimeEntry) ([]byte, error) {
 	return session.delete(TogglAPI, resource.GenerateResourceURLWithID(resource.TimeEntries, timer.Wid, timer.ID))
 }
 
-// GetProjects allows to query for all projects in a workspace
-func (session *Session) GetProjects(wid int) ([]Project, error) {
+// GetProjects allows to query for all projects in a workspace. Only active
+// projects are returned, unless another state is given.
+func (session *Session) GetProjects(wid int, state ...ProjectState) ([]Project, error) {
 	session.logger.Debug("getting projects for workspace", "workspaceID", wid)
 
+	if len(state) > 0 && state[0] != ActiveProjects {
+		return session.getProjectsByState(wid, state[0])
+	}
+
 	plist := make([]Project, 0)
 
 	// try cache first
@@ -309,6 +693,7 @@ func (session *Session) GetProjects(wid int) ([]Project, error) {
 		return nil, err
 	}
 
+	session.cache.Init(resource.Projects, wid)
 	for _, p := range plist {
 		session.cache.Set(resource.Projects, wid, p.ID, p)
 	}
@@ -334,7 +719,7 @@ func (session *Session) GetProject(id int, wid int) (project Project, err error)
 		return project, err
 	}
 
-	session.cache.Set(resource.Projects, wid, id, project)
+	session.cache.Update(resource.Projects, wid, id, project)
 	return project, nil
 }
 
@@ -357,6 +742,7 @@ func (session *Session) CreateProject(name string, wid int) (project Project, er
 		return project, err
 	}
 
+	session.cache.Update(resource.Projects, wid, project.ID, project)
 	return project, nil
 }
 
@@ -379,13 +765,118 @@ func (session *Session) UpdateProject(project Project) (Project, error) {
 		return Project{}, err
 	}
 
+	session.cache.Update(resource.Projects, project.Wid, entry.ID, entry)
 	return entry, nil
 }
 
 // DeleteProject deletes a project.
 func (session *Session) DeleteProject(project Project) ([]byte, error) {
 	session.logger.Debug("deleting project", "project", project)
-	return session.delete(TogglAPI, resource.GenerateResourceURLWithID(resource.Projects, project.Wid, project.ID))
+	data, err := session.delete(TogglAPI, resource.GenerateResourceURLWithID(resource.Projects, project.Wid, project.ID))
+	if err != nil {
+		return data, err
+	}
+
+	session.cache.Delete(resource.Projects, project.Wid, project.ID)
+	return data, nil
+}
+
+// GetTags returns the tags of a workspace
+func (session *Session) GetTags(wid int) ([]Tag, error) {
+	session.logger.Debug("getting tags for workspace", "workspaceID", wid)
+
+	tlist := make([]Tag, 0)
+
+	// try cache first
+	if tmap, ok := session.cache.GetMap(resource.Tags, wid); ok {
+		for _, tentry := range tmap {
+			tlist = append(tlist, tentry.(Tag))
+		}
+		return tlist, nil
+	}
+
+	data, err := session.get(TogglAPI, resource.GenerateResourceURL(resource.Tags, wid), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, &tlist)
+	if err != nil {
+		return nil, err
+	}
+
+	session.cache.Init(resource.Tags, wid)
+	for _, t := range tlist {
+		session.cache.Set(resource.Tags, wid, t.ID, t)
+	}
+	return tlist, nil
+}
+
+// GetTag returns a single tag in a workspace
+func (session *Session) GetTag(id int, wid int) (Tag, error) {
+	session.logger.Debug("getting tag", "tagID", id)
+
+	// try cache first
+	if tentry, ok := session.cache.Get(resource.Tags, wid, id); ok {
+		return tentry.(Tag), nil
+	}
+
+	// the API has no endpoint for a single tag
+	tags, err := session.GetTags(wid)
+	if err != nil {
+		return Tag{}, err
+	}
+
+	for _, t := range tags {
+		if t.ID == id {
+			return t, nil
+		}
+	}
+
+	return Tag{}, fmt.Errorf("tag %d not found in workspace %d", id, wid)
+}
+
+// FindTagByName returns the tag of a workspace whose name matches the given
+// one, ignoring case. The boolean is false if no such tag exists.
+func (session *Session) FindTagByName(wid int, name string) (Tag, bool, error) {
+	tags, err := session.GetTags(wid)
+	if err != nil {
+		return Tag{}, false, err
+	}
+
+	for _, t := range tags {
+		if strings.EqualFold(t.Name, name) {
+			return t, true, nil
+		}
+	}
+
+	return Tag{}, false, nil
+}
+
+// EnsureTag returns the tag with the given name, creating it if it does not
+// exist yet in the workspace.
+func (session *Session) EnsureTag(wid int, name string) (Tag, error) {
+	tag, ok, err := session.FindTagByName(wid, name)
+	if err != nil {
+		return Tag{}, err
+	}
+	if ok {
+		return tag, nil
+	}
+
+	return session.CreateTag(name, wid)
+}
+
+// EnsureTimeEntryTags creates the tags of a time entry that do not exist yet
+// in its workspace. It should be called before the entry is saved.
+func (session *Session) EnsureTimeEntryTags(timer TimeEntry) error {
+	for _, name := range timer.Tags {
+		if _, err := session.EnsureTag(timer.Wid, name); err != nil {
+			return fmt.Errorf("error ensuring tag %q: %v", name, err)
+		}
+	}
+
+	return nil
 }
 
 // CreateTag creates a new tag.
@@ -406,6 +897,7 @@ func (session *Session) CreateTag(name string, wid int) (tag Tag, err error) {
 		return tag, err
 	}
 
+	session.cache.Update(resource.Tags, wid, tag.ID, tag)
 	return tag, nil
 }
 
@@ -424,25 +916,48 @@ func (session *Session) UpdateTag(tag Tag) (Tag, error) {
 		return Tag{}, err
 	}
 
+	session.cache.Update(resource.Tags, tag.Wid, entry.ID, entry)
 	return entry, nil
 }
 
 // DeleteTag deletes a tag.
 func (session *Session) DeleteTag(tag Tag) ([]byte, error) {
 	session.logger.Debug("deleting tag", "tag", tag)
-	return session.delete(TogglAPI, resource.GenerateResourceURLWithID(resource.Tags, tag.Wid, tag.ID))
+	data, err := session.delete(TogglAPI, resource.GenerateResourceURLWithID(resource.Tags, tag.Wid, tag.ID))
+	if err != nil {
+		return data, err
+	}
+
+	session.cache.Delete(resource.Tags, tag.Wid, tag.ID)
+	return data, nil
 }
 
 // GetClients returns a list of clients for the current account
 func (session *Session) GetClients(wid int) (list []Client, err error) {
 	session.logger.Debug("retrieving clients")
 
+	// try cache first
+	if cmap, ok := session.cache.GetMap(resource.Clients, wid); ok {
+		for _, centry := range cmap {
+			list = append(list, centry.(Client))
+		}
+		return list, nil
+	}
+
 	data, err := session.get(TogglAPI, resource.GenerateResourceURL(resource.Clients, wid), nil)
 	if err != nil {
 		return list, err
 	}
 	err = json.Unmarshal(data, &list)
-	return list, err
+	if err != nil {
+		return list, err
+	}
+
+	session.cache.Init(resource.Clients, wid)
+	for _, c := range list {
+		session.cache.Set(resource.Clients, wid, c.ID, c)
+	}
+	return list, nil
 }
 
 // CreateClient adds a new client
@@ -462,12 +977,125 @@ func (session *Session) CreateClient(name string, wid int) (client Client, err e
 	if err != nil {
 		return client, err
 	}
+
+	session.cache.Update(resource.Clients, wid, client.ID, client)
 	return client, nil
 }
 
+// GetWorkspaceUsers returns the members of a workspace
+func (session *Session) GetWorkspaceUsers(wid int) ([]User, error) {
+	session.logger.Debug("getting users for workspace", "workspaceID", wid)
+
+	data, err := session.get(TogglAPI, resource.GenerateResourceURL(resource.Users, wid), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]User, 0)
+	err = json.Unmarshal(data, &users)
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// DeleteClient deletes a client.
+func (session *Session) DeleteClient(client Client) ([]byte, error) {
+	session.logger.Debug("deleting client", "client", client)
+	data, err := session.delete(TogglAPI, resource.GenerateResourceURLWithID(resource.Clients, client.Wid, client.ID))
+	if err != nil {
+		return data, err
+	}
+
+	session.cache.Delete(resource.Clients, client.Wid, client.ID)
+	return data, nil
+}
+
+// GetTasks returns the tasks of all the projects in a workspace
+func (session *Session) GetTasks(wid int) ([]Task, error) {
+	session.logger.Debug("getting tasks for workspace", "workspaceID", wid)
+
+	tlist := make([]Task, 0)
+
+	// try cache first
+	if tmap, ok := session.cache.GetMap(resource.Tasks, wid); ok {
+		for _, tentry := range tmap {
+			tlist = append(tlist, tentry.(Task))
+		}
+		return tlist, nil
+	}
+
+	// this endpoint is paginated, unlike the other workspace resources
+	for page := 1; ; page++ {
+		data, err := session.get(
+			TogglAPI,
+			resource.GenerateResourceURL(resource.Tasks, wid),
+			map[string]string{"page": fmt.Sprintf("%d", page)},
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		var resp struct {
+			Data       []Task `json:"data"`
+			TotalCount int    `json:"total_count"`
+		}
+		err = json.Unmarshal(data, &resp)
+		if err != nil {
+			return nil, err
+		}
+
+		tlist = append(tlist, resp.Data...)
+		if len(resp.Data) == 0 || len(tlist) >= resp.TotalCount {
+			break
+		}
+	}
+
+	session.cache.Init(resource.Tasks, wid)
+	for _, t := range tlist {
+		session.cache.Set(resource.Tasks, wid, t.ID, t)
+	}
+	return tlist, nil
+}
+
+// CreateTask creates a new task in the project given by task.Pid.
+func (session *Session) CreateTask(task Task) (Task, error) {
+	session.logger.Debug("creating task", "taskName", task.Name, "projectID", task.Pid)
+	data := map[string]interface{}{
+		"name":   task.Name,
+		"active": task.Active,
+	}
+	if task.EstimatedSeconds != nil {
+		data["estimated_seconds"] = *task.EstimatedSeconds
+	}
+
+	respData, err := session.post(
+		TogglAPI,
+		resource.GenerateResourceURLWithID(resource.Projects, task.Wid, task.Pid)+"/"+resource.Tasks.String(),
+		data,
+	)
+	if err != nil {
+		return Task{}, err
+	}
+
+	var created Task
+	err = json.Unmarshal(respData, &created)
+	if err != nil {
+		return Task{}, err
+	}
+
+	session.cache.Update(resource.Tasks, task.Wid, created.ID, created)
+	return created, nil
+}
+
 func (session *Session) request(method string, requestURL string, body io.Reader) ([]byte, error) {
 	retryClient := retryablehttp.NewClient()
 	retryClient.RetryMax = 10
+	if session.offline != nil {
+		// fail fast so that mutations get journaled
+		retryClient.RetryMax = offlineRetryMax
+	}
 	retryClient.Logger = session.logger
 
 	client := retryClient.StandardClient() // *http.Client
@@ -487,7 +1115,7 @@ func (session *Session) request(method string, requestURL string, body io.Reader
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("error making request: %v", err)
+		return nil, fmt.Errorf("error making request: %w (%v)", ErrUnreachable, err)
 	}
 	defer resp.Body.Close()
 
@@ -551,10 +1179,20 @@ func (session *Session) put(requestURL string, path string, data interface{}) ([
 	return session.request("PUT", requestURL, bytes.NewBuffer(body))
 }
 
-func (session *Session) patch(requestURL string, path string) ([]byte, error) {
+func (session *Session) patch(requestURL string, path string, data interface{}) ([]byte, error) {
 	requestURL += path
+	var body io.Reader
+
+	if data != nil {
+		b, err := json.Marshal(data)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewBuffer(b)
+	}
+
 	session.logger.Debug("PATCHing URL", "url", requestURL)
-	return session.request("PATCH", requestURL, nil)
+	return session.request("PATCH", requestURL, body)
 }
 
 func (session *Session) delete(requestURL string, path string) ([]byte, error) {
@@ -572,6 +1210,20 @@ func (session *Session) delete(requestURL string, path string) ([]byte, error) {
 // 	return nil
 // }
 
+// InvalidateCache drops the cached resources of the given types, or of all
+// types if none is given, so that they are fetched again from the API.
+func (session *Session) InvalidateCache(types ...resource.Type) {
+	if len(types) == 0 {
+		for rt := range resource.TypeMap {
+			types = append(types, rt)
+		}
+	}
+
+	for _, rt := range types {
+		session.cache.Clear(rt)
+	}
+}
+
 // ShowStats prints stats for all resource types
 func (s *Session) ShowStats(wid int) {
 	for rt := range resource.TypeMap {
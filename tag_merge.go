@@ -0,0 +1,133 @@
+package toggl
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MergeTagsOptions tunes MergeTags.
+type MergeTagsOptions struct {
+	// DryRun reports what would change without changing anything.
+	DryRun bool
+	// Since and Until restrict the time entries that are rewritten. They
+	// default to the whole history of the workspace. The obsolete tags are
+	// kept when the time entries are restricted, since older or newer
+	// entries still use them.
+	Since time.Time
+	Until time.Time
+	// Period restricts the time entries instead of Since and Until, unless
+	// it is zero.
+	Period Period
+}
+
+// MergeTagsResult describes the changes made (or planned, in dry-run mode) by
+// MergeTags.
+type MergeTagsResult struct {
+	DryRun bool
+	// Into is the tag the other ones were merged into. Its ID is zero if it
+	// does not exist yet and the merge was a dry run.
+	Into Tag
+	// TimeEntries holds the IDs of the rewritten time entries.
+	TimeEntries []int
+	// DeletedTags holds the obsolete tags, deleted only when the whole
+	// history was rewritten.
+	DeletedTags []Tag
+}
+
+// MergeTags replaces the tags named in from by the tag named into in every
+// time entry of a workspace, then deletes the obsolete tags unless the time
+// entries were restricted by the options. The into tag is created if needed.
+// Tag names are matched case-insensitively.
+func (session *Session) MergeTags(wid int, from []string, into string, opts MergeTagsOptions) (MergeTagsResult, error) {
+	result := MergeTagsResult{DryRun: opts.DryRun}
+
+	target, ok, err := session.FindTagByName(wid, into)
+	if err != nil {
+		return result, err
+	}
+	if !ok {
+		target = Tag{Wid: wid, Name: into}
+		if !opts.DryRun {
+			target, err = session.CreateTag(into, wid)
+			if err != nil {
+				return result, fmt.Errorf("error creating tag %q: %v", into, err)
+			}
+		}
+	}
+	result.Into = target
+
+	var obsolete []Tag
+	for _, name := range from {
+		tag, ok, err := session.FindTagByName(wid, name)
+		if err != nil {
+			return result, err
+		}
+		if !ok || (target.ID != 0 && tag.ID == target.ID) {
+			continue
+		}
+		obsolete = append(obsolete, tag)
+	}
+	if len(obsolete) == 0 {
+		return result, nil
+	}
+
+	tagIDs := make([]string, len(obsolete))
+	names := make([]string, len(obsolete))
+	for i, t := range obsolete {
+		tagIDs[i] = fmt.Sprintf("%d", t.ID)
+		names[i] = t.Name
+	}
+
+	since, until := opts.Since, opts.Until
+	if !opts.Period.IsZero() {
+		since, until = opts.Period.Start, opts.Period.Last()
+	}
+	if since.IsZero() {
+		since = Epoch
+	}
+	if until.IsZero() {
+		until = time.Now()
+	}
+
+	session.logger.Debug("merging tags", "from", names, "into", into, "dryRun", opts.DryRun)
+
+	err = session.forEachDetailedTimeEntry(wid, since, until, map[string]string{"tag_ids": strings.Join(tagIDs, ",")},
+		func(entry DetailedTimeEntry) error {
+			result.TimeEntries = append(result.TimeEntries, entry.ID)
+			return nil
+		})
+	if err != nil {
+		return result, fmt.Errorf("error listing tagged time entries: %v", err)
+	}
+
+	// entries out of a restricted range keep the obsolete tags
+	wholeHistory := opts.Since.IsZero() && opts.Until.IsZero() && opts.Period.IsZero()
+
+	if opts.DryRun {
+		if wholeHistory {
+			result.DeletedTags = obsolete
+		}
+		return result, nil
+	}
+
+	err = session.patchTimeEntries(wid, result.TimeEntries, []timeEntryPatch{
+		{Op: "add", Path: "/tags", Value: []string{target.Name}},
+		{Op: "remove", Path: "/tags", Value: names},
+	})
+	if err != nil {
+		return result, fmt.Errorf("error rewriting time entries: %v", err)
+	}
+
+	if !wholeHistory {
+		return result, nil
+	}
+	for _, tag := range obsolete {
+		if _, err := session.DeleteTag(tag); err != nil {
+			return result, fmt.Errorf("error deleting tag %q: %v", tag.Name, err)
+		}
+		result.DeletedTags = append(result.DeletedTags, tag)
+	}
+
+	return result, nil
+}
@@ -3,6 +3,7 @@ package toggl
 import (
 	"encoding/json"
 	"fmt"
+	"slices"
 	"time"
 )
 
@@ -26,8 +27,46 @@ type TimeEntry struct {
 	Duration    int64      `json:"duration,omitempty"`
 	DurOnly     bool       `json:"duronly"`
 	Billable    bool       `json:"billable"`
+	// The fields below are only set by the API. At is when the entry was
+	// last updated, ServerDeletedAt when it was deleted; unlike Start and
+	// Stop, they encode back as they were received. The names are only set
+	// when listing entries with their metadata. TagIDs can also be set to
+	// update the tags of an entry by ID: they are only sent when they differ
+	// from the decoded ones.
+	At              *Timestamp            `json:"at,omitempty"`
+	ServerDeletedAt *Timestamp            `json:"server_deleted_at,omitempty"`
+	UserID          int                   `json:"user_id,omitempty"`
+	TagIDs          []int                 `json:"tag_ids,omitempty"`
+	SharedWith      []TimeEntrySharedWith `json:"shared_with,omitempty"`
+	Permissions     []string              `json:"permissions,omitempty"`
+	ClientName      string                `json:"client_name,omitempty"`
+	ProjectName     string                `json:"project_name,omitempty"`
+	ProjectColor    string                `json:"project_color,omitempty"`
+	ProjectActive   *bool                 `json:"project_active,omitempty"`
+	ProjectBillable *bool                 `json:"project_billable,omitempty"`
+	UserName        string                `json:"user_name,omitempty"`
+
+	// decodedTagIDs are the tag IDs the entry was decoded with.
+	decodedTagIDs []int
 }
 
+// TimeEntrySharedWith is a user a time entry is shared with.
+type TimeEntrySharedWith struct {
+	UserID   int    `json:"user_id"`
+	UserName string `json:"user_name"`
+	Accepted bool   `json:"accepted"`
+}
+
+// ExpandedTimeEntry is a time entry with its project, client and task, nil
+// when it has none.
+type ExpandedTimeEntry struct {
+	TimeEntry
+	Project *Project `json:"project,omitempty"`
+	Client  *Client  `json:"client,omitempty"`
+	Task    *Task    `json:"task,omitempty"`
+}
+
+// DetailedTimeEntry is a time entry of the detailed report.
 type DetailedTimeEntry struct {
 	ID              int        `json:"id"`
 	Pid             int        `json:"pid"`
@@ -39,9 +78,9 @@ type DetailedTimeEntry struct {
 	ProjectColor    string     `json:"project_color"`
 	ProjectHexColor string     `json:"project_hex_color"`
 	Client          string     `json:"client"`
-	Start           *time.Time `json:"start"`
-	End             *time.Time `json:"end"`
-	Updated         *time.Time `json:"updated"`
+	Start           *Timestamp `json:"start"`
+	End             *Timestamp `json:"end"`
+	Updated         *Timestamp `json:"updated"`
 	Duration        int64      `json:"dur"`
 	Billable        bool       `json:"billable"`
 	Tags            []string   `json:"tags"`
@@ -57,6 +96,7 @@ type timeEntryCreate struct {
 	Stop        *time.Time `json:"stop,omitempty"`
 	Tags        []string   `json:"tags"`
 	WorkspaceId int        `json:"workspace_id"`
+	UserID      *int       `json:"user_id,omitempty"`
 }
 
 // This is an alias for TimeEntry that is used in tempTimeEntry to prevent the
@@ -66,40 +106,20 @@ type embeddedTimeEntry TimeEntry
 // tempTimeEntry is an intermediate type used as for decoding TimeEntries.
 type tempTimeEntry struct {
 	embeddedTimeEntry
-	Stop  string `json:"stop"`
-	Start string `json:"start"`
+	Stop            *Timestamp `json:"stop"`
+	Start           *Timestamp `json:"start"`
+	At              *Timestamp `json:"at"`
+	ServerDeletedAt *Timestamp `json:"server_deleted_at"`
 }
 
-func (t *tempTimeEntry) asTimeEntry() (entry TimeEntry, err error) {
-	entry = TimeEntry(t.embeddedTimeEntry)
-
-	parseTime := func(s string) (t time.Time, err error) {
-		t, err = time.Parse("2006-01-02T15:04:05Z", s)
-		if err != nil {
-			t, err = time.Parse("2006-01-02T15:04:05-07:00", s)
-		}
-		return
-	}
-
-	if t.Start != "" {
-		var start time.Time
-		start, err = parseTime(t.Start)
-		if err != nil {
-			return
-		}
-		entry.Start = &start
-	}
-
-	if t.Stop != "" {
-		var stop time.Time
-		stop, err = parseTime(t.Stop)
-		if err != nil {
-			return
-		}
-		entry.Stop = &stop
-	}
-
-	return
+func (t *tempTimeEntry) asTimeEntry() TimeEntry {
+	entry := TimeEntry(t.embeddedTimeEntry)
+	entry.Start = t.Start.timePtr()
+	entry.Stop = t.Stop.timePtr()
+	entry.At = t.At.orNil()
+	entry.ServerDeletedAt = t.ServerDeletedAt.orNil()
+	entry.decodedTagIDs = slices.Clone(entry.TagIDs)
+	return entry
 }
 
 func handleTimeEntryResponse(data []byte, err error) (TimeEntry, error) {
@@ -127,6 +147,25 @@ func (t timeEntryCreate) MarshalJSON() ([]byte, error) {
 	})
 }
 
+// asTimeEntry returns the time entry that t would create.
+func (t timeEntryCreate) asTimeEntry() TimeEntry {
+	entry := TimeEntry{
+		Wid:         t.WorkspaceId,
+		Pid:         t.ProjectID,
+		Tid:         t.TaskID,
+		Description: t.Description,
+		Start:       t.Start,
+		Stop:        t.Stop,
+		Tags:        t.Tags,
+		Duration:    int64(t.Duration),
+		Billable:    t.Billable,
+	}
+	if t.UserID != nil {
+		entry.UserID = *t.UserID
+	}
+	return entry
+}
+
 func (t timeEntryCreate) withMetadataFromTimeEntry(timeEntry TimeEntry) timeEntryCreate {
 	t.ProjectID = timeEntry.Pid
 	t.TaskID = timeEntry.Tid
@@ -136,6 +175,32 @@ func (t timeEntryCreate) withMetadataFromTimeEntry(timeEntry TimeEntry) timeEntr
 	return t
 }
 
+// newCreateEntryRequestData returns the data creating a copy of a time entry,
+// running or not.
+func newCreateEntryRequestData(timeEntry TimeEntry) timeEntryCreate {
+	entry := timeEntryCreate{
+		Description: timeEntry.Description,
+		Duration:    int(timeEntry.Duration),
+		Start:       timeEntry.Start,
+		Stop:        timeEntry.Stop,
+		WorkspaceId: timeEntry.Wid,
+	}
+	if timeEntry.UserID != 0 {
+		uid := timeEntry.UserID
+		entry.UserID = &uid
+	}
+	if entry.Start == nil {
+		now := time.Now()
+		entry.Start = &now
+	}
+	if timeEntry.IsRunning() {
+		entry.Duration = -1
+		entry.Stop = nil
+	}
+
+	return entry.withMetadataFromTimeEntry(timeEntry)
+}
+
 func newStartEntryRequestData(description string, workspaceId int) timeEntryCreate {
 	now := time.Now()
 	return timeEntryCreate{
@@ -146,21 +211,89 @@ func newStartEntryRequestData(description string, workspaceId int) timeEntryCrea
 	}
 }
 
+// TimeEntry returns the report entry as a time entry of the workspace wid.
+func (e DetailedTimeEntry) TimeEntry(wid int) TimeEntry {
+	entry := TimeEntry{
+		Wid:         wid,
+		ID:          e.ID,
+		UserID:      e.Uid,
+		Description: e.Description,
+		Start:       e.Start.timePtr(),
+		Stop:        e.End.timePtr(),
+		Tags:        e.Tags,
+		// reports durations are in milliseconds
+		Duration: e.Duration / 1000,
+		Billable: e.Billable,
+		At:       e.Updated,
+	}
+	if e.Pid != 0 {
+		pid := e.Pid
+		entry.Pid = &pid
+	}
+	if e.Tid != 0 {
+		tid := e.Tid
+		entry.Tid = &tid
+	}
+	return entry
+}
+
 // IsRunning returns true if the receiver is currently running.
 func (e *TimeEntry) IsRunning() bool {
 	return e.Duration < 0
 }
 
+// forUpdate returns the entry without the fields only set by the API. Tag
+// IDs are only sent when the caller changed them, so that the stale IDs of an
+// entry whose tag names changed do not restore its tags.
+func (e TimeEntry) forUpdate() TimeEntry {
+	e.At = nil
+	e.ServerDeletedAt = nil
+	e.UserID = 0
+	e.TagIDs = e.changedTagIDs()
+	e.SharedWith = nil
+	e.Permissions = nil
+	e.ClientName = ""
+	e.ProjectName = ""
+	e.ProjectColor = ""
+	e.ProjectActive = nil
+	e.ProjectBillable = nil
+	e.UserName = ""
+	return e
+}
+
+// changedTagIDs returns the tag IDs of the entry, nil when they are the ones
+// it was decoded with.
+func (e TimeEntry) changedTagIDs() []int {
+	if slices.Equal(e.TagIDs, e.decodedTagIDs) {
+		return nil
+	}
+	return e.TagIDs
+}
+
 // Copy returns a copy of a TimeEntry.
 func (e *TimeEntry) Copy() TimeEntry {
 	newEntry := *e
 	newEntry.Tags = make([]string, len(e.Tags))
 	copy(newEntry.Tags, e.Tags)
+	if e.TagIDs != nil {
+		newEntry.TagIDs = make([]int, len(e.TagIDs))
+		copy(newEntry.TagIDs, e.TagIDs)
+	}
+	if e.SharedWith != nil {
+		newEntry.SharedWith = make([]TimeEntrySharedWith, len(e.SharedWith))
+		copy(newEntry.SharedWith, e.SharedWith)
+	}
+	if e.Permissions != nil {
+		newEntry.Permissions = make([]string, len(e.Permissions))
+		copy(newEntry.Permissions, e.Permissions)
+	}
 	if e.Start != nil {
-		*newEntry.Start = *e.Start
+		start := *e.Start
+		newEntry.Start = &start
 	}
 	if e.Stop != nil {
-		*newEntry.Stop = *e.Stop
+		stop := *e.Stop
+		newEntry.Stop = &stop
 	}
 	return newEntry
 }
@@ -253,18 +386,57 @@ func indexOfTag(tag string, tags []string) int {
 	return -1
 }
 
-// UnmarshalJSON unmarshals a TimeEntry from JSON data, converting timestamp
-// fields to Go Time values.
+// UnmarshalJSON unmarshals a TimeEntry from JSON data, accepting the
+// timestamp variants of Timestamp.
 func (e *TimeEntry) UnmarshalJSON(b []byte) error {
 	var entry tempTimeEntry
 	err := json.Unmarshal(b, &entry)
 	if err != nil {
 		return err
 	}
-	te, err := entry.asTimeEntry()
+	*e = entry.asTimeEntry()
+	return nil
+}
+
+// UnmarshalJSON unmarshals an ExpandedTimeEntry from JSON data, which would
+// otherwise be left to the UnmarshalJSON method of the embedded TimeEntry.
+func (e *ExpandedTimeEntry) UnmarshalJSON(b []byte) error {
+	var related struct {
+		Project *Project `json:"project"`
+		Client  *Client  `json:"client"`
+		Task    *Task    `json:"task"`
+	}
+	err := json.Unmarshal(b, &related)
 	if err != nil {
 		return err
 	}
-	*e = te
+
+	err = json.Unmarshal(b, &e.TimeEntry)
+	if err != nil {
+		return err
+	}
+	e.Project, e.Client, e.Task = related.Project, related.Client, related.Task
+	return nil
+}
+
+// UnmarshalJSON unmarshals a DetailedTimeEntry from JSON data, accepting
+// the timestamp variants of Timestamp.
+func (e *DetailedTimeEntry) UnmarshalJSON(b []byte) error {
+	type embeddedDetailedTimeEntry DetailedTimeEntry
+	var entry struct {
+		embeddedDetailedTimeEntry
+		Start   *Timestamp `json:"start"`
+		End     *Timestamp `json:"end"`
+		Updated *Timestamp `json:"updated"`
+	}
+	err := json.Unmarshal(b, &entry)
+	if err != nil {
+		return err
+	}
+
+	*e = DetailedTimeEntry(entry.embeddedDetailedTimeEntry)
+	e.Start = entry.Start.orNil()
+	e.End = entry.End.orNil()
+	e.Updated = entry.Updated.orNil()
 	return nil
 }
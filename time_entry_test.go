@@ -0,0 +1,72 @@
+package toggl
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestTimeEntryForUpdateTagIDs(t *testing.T) {
+	const data = `{"id":1,"workspace_id":2,"tags":["a","b"],"tag_ids":[10,20]}`
+
+	tests := []struct {
+		name   string
+		change func(*TimeEntry)
+		want   []int
+	}{
+		{"unchanged", func(e *TimeEntry) {}, nil},
+		{"last tags removed", func(e *TimeEntry) { e.RemoveTag("a"); e.RemoveTag("b") }, nil},
+		{"tags cleared", func(e *TimeEntry) { e.Tags = nil }, nil},
+		{"tag added", func(e *TimeEntry) { e.AddTag("c") }, nil},
+		{"tag IDs set", func(e *TimeEntry) { e.Tags = nil; e.TagIDs = []int{30} }, []int{30}},
+		{"tag ID appended", func(e *TimeEntry) { e.TagIDs = append(e.TagIDs, 30) }, []int{10, 20, 30}},
+		{"tag IDs cleared", func(e *TimeEntry) { e.Tags = nil; e.TagIDs = []int{} }, nil},
+	}
+
+	for _, tt := range tests {
+		var entry TimeEntry
+		if err := json.Unmarshal([]byte(data), &entry); err != nil {
+			t.Fatal(err)
+		}
+		entry = entry.Copy()
+		tt.change(&entry)
+
+		got := entry.forUpdate()
+		if !slices.Equal(got.TagIDs, tt.want) {
+			t.Errorf("%s: forUpdate().TagIDs = %v, want %v", tt.name, got.TagIDs, tt.want)
+		}
+		encoded, err := json.Marshal(got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tt.want == nil && strings.Contains(string(encoded), "tag_ids") {
+			t.Errorf("%s: update encoded as %s", tt.name, encoded)
+		}
+	}
+}
+
+func TestTimeEntryForUpdateNewTagIDs(t *testing.T) {
+	entry := TimeEntry{ID: 1, Wid: 2, TagIDs: []int{10}}
+	if got := entry.forUpdate(); !slices.Equal(got.TagIDs, []int{10}) {
+		t.Errorf("forUpdate().TagIDs = %v, want [10]", got.TagIDs)
+	}
+}
+
+func TestMergedTimeEntryTagIDs(t *testing.T) {
+	var base TimeEntry
+	if err := json.Unmarshal([]byte(`{"id":1,"workspace_id":2,"tags":["a"],"tag_ids":[10]}`), &base); err != nil {
+		t.Fatal(err)
+	}
+	var server TimeEntry
+	if err := json.Unmarshal([]byte(`{"id":1,"workspace_id":2,"description":"Review","tags":["a"],"tag_ids":[10]}`), &server); err != nil {
+		t.Fatal(err)
+	}
+	local := base.Copy()
+	local.RemoveTag("a")
+
+	merged, _ := mergeTimeEntries(base, local, server)
+	if got := merged.forUpdate(); len(got.Tags) != 0 || got.TagIDs != nil {
+		t.Errorf("merged update has tags %q and tag IDs %v", got.Tags, got.TagIDs)
+	}
+}
@@ -0,0 +1,96 @@
+package toggl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// timestampLayouts are the layouts accepted by Timestamp, tried in order.
+// Fractional seconds are accepted by all of them.
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02T15:04:05.999999999Z07",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z0700",
+	"2006-01-02 15:04:05.999999999Z07",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+// Timestamp is a time decoded from the timestamps sent by Toggl, which are
+// not always RFC 3339: fractional seconds, offsets without colons or minutes,
+// spaces instead of the T separator and missing time zones (taken as UTC)
+// are accepted. A decoded Timestamp encodes back to the same text unless
+// its time is changed.
+type Timestamp struct {
+	time.Time
+
+	// text and parsed are the decoded text and its time
+	text   string
+	parsed time.Time
+}
+
+// ParseTimestamp parses a timestamp as sent by Toggl.
+func ParseTimestamp(s string) (Timestamp, error) {
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return Timestamp{Time: t, text: s, parsed: t}, nil
+		}
+	}
+	return Timestamp{}, fmt.Errorf("invalid timestamp %q", s)
+}
+
+// UnmarshalJSON decodes a timestamp string. Null and empty strings give a
+// zero timestamp.
+func (t *Timestamp) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, []byte("null")) {
+		*t = Timestamp{}
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("invalid timestamp %s", b)
+	}
+	if s == "" {
+		*t = Timestamp{}
+		return nil
+	}
+
+	ts, err := ParseTimestamp(s)
+	if err != nil {
+		return err
+	}
+	*t = ts
+	return nil
+}
+
+// MarshalJSON encodes the timestamp as it was decoded, or as RFC 3339 with
+// fractional seconds when its time was changed.
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	if t.text != "" && t.Time.Equal(t.parsed) {
+		return json.Marshal(t.text)
+	}
+	return t.Time.MarshalJSON()
+}
+
+// orNil returns the decoded timestamp, nil when it is missing.
+func (t *Timestamp) orNil() *Timestamp {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+// timePtr returns the time of a decoded timestamp, nil when it is missing.
+func (t *Timestamp) timePtr() *time.Time {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	tm := t.Time
+	return &tm
+}
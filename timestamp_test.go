@@ -0,0 +1,209 @@
+package toggl
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTimestamp(t *testing.T) {
+	tests := []struct {
+		text string
+		want time.Time
+	}{
+		{"2026-10-17T14:05:09Z", time.Date(2026, 10, 17, 14, 5, 9, 0, time.UTC)},
+		{"2026-10-17T14:05:09.123456Z", time.Date(2026, 10, 17, 14, 5, 9, 123456000, time.UTC)},
+		{"2026-10-17T16:05:09+02:00", time.Date(2026, 10, 17, 14, 5, 9, 0, time.UTC)},
+		{"2026-10-17T16:05:09.5+02:00", time.Date(2026, 10, 17, 14, 5, 9, 500000000, time.UTC)},
+		{"2026-10-17T16:05:09+0200", time.Date(2026, 10, 17, 14, 5, 9, 0, time.UTC)},
+		{"2026-10-17T16:05:09+02", time.Date(2026, 10, 17, 14, 5, 9, 0, time.UTC)},
+		{"2026-10-17 16:05:09+02:00", time.Date(2026, 10, 17, 14, 5, 9, 0, time.UTC)},
+		{"2026-10-17 16:05:09.25+0200", time.Date(2026, 10, 17, 14, 5, 9, 250000000, time.UTC)},
+		{"2026-10-17 16:05:09+02", time.Date(2026, 10, 17, 14, 5, 9, 0, time.UTC)},
+		{"2026-10-17 14:05:09Z", time.Date(2026, 10, 17, 14, 5, 9, 0, time.UTC)},
+		{"2026-10-17T14:05:09", time.Date(2026, 10, 17, 14, 5, 9, 0, time.UTC)},
+		{"2026-10-17T14:05:09.001", time.Date(2026, 10, 17, 14, 5, 9, 1000000, time.UTC)},
+		{"2026-10-17 14:05:09", time.Date(2026, 10, 17, 14, 5, 9, 0, time.UTC)},
+		{"2026-10-17", time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		ts, err := ParseTimestamp(tt.text)
+		if err != nil {
+			t.Errorf("ParseTimestamp(%q): %v", tt.text, err)
+			continue
+		}
+		if !ts.Equal(tt.want) {
+			t.Errorf("ParseTimestamp(%q) = %v, want %v", tt.text, ts.Time, tt.want)
+		}
+	}
+}
+
+func TestParseTimestampInvalid(t *testing.T) {
+	for _, text := range []string{"", "yesterday", "2026-13-01", "2026-10-17T25:00:00Z", "17/10/2026"} {
+		if _, err := ParseTimestamp(text); err == nil {
+			t.Errorf("ParseTimestamp(%q) succeeded", text)
+		}
+	}
+}
+
+func TestTimestampJSON(t *testing.T) {
+	tests := []struct {
+		json string
+		zero bool
+	}{
+		{`null`, true},
+		{`""`, true},
+		{`"2026-10-17T14:05:09.123+0000"`, false},
+		{`"2026-10-17 14:05:09"`, false},
+	}
+
+	for _, tt := range tests {
+		var ts Timestamp
+		if err := json.Unmarshal([]byte(tt.json), &ts); err != nil {
+			t.Errorf("decoding %s: %v", tt.json, err)
+			continue
+		}
+		if ts.IsZero() != tt.zero {
+			t.Errorf("decoding %s: zero = %v, want %v", tt.json, ts.IsZero(), tt.zero)
+		}
+		if tt.zero {
+			continue
+		}
+
+		data, err := json.Marshal(ts)
+		if err != nil {
+			t.Errorf("encoding %s: %v", tt.json, err)
+			continue
+		}
+		if string(data) != tt.json {
+			t.Errorf("round trip of %s gave %s", tt.json, data)
+		}
+	}
+
+	for _, text := range []string{`42`, `"soon"`, `{}`} {
+		var ts Timestamp
+		if err := json.Unmarshal([]byte(text), &ts); err == nil {
+			t.Errorf("decoding %s succeeded", text)
+		}
+	}
+}
+
+func TestTimestampChanged(t *testing.T) {
+	ts, err := ParseTimestamp("2026-10-17 14:05:09")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts.Time = ts.Add(time.Hour)
+	data, err := json.Marshal(ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `"2026-10-17T15:05:09Z"`; string(data) != want {
+		t.Errorf("changed timestamp encoded as %s, want %s", data, want)
+	}
+}
+
+func TestTimestampRoundTrip(t *testing.T) {
+	const at = "2026-10-17T14:05:09.123456+00:00"
+	const deleted = "2026-10-18 08:00:00+02"
+
+	var project Project
+	if err := json.Unmarshal([]byte(`{"id":1,"name":"Website","server_deleted_at":"`+deleted+`"}`), &project); err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"server_deleted_at":"`+deleted+`"`) {
+		t.Errorf("project encoded as %s", data)
+	}
+
+	var entry TimeEntry
+	if err := json.Unmarshal([]byte(`{"id":2,"start":"2026-10-17T13:00:00Z","at":"`+at+`","server_deleted_at":""}`), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry.ServerDeletedAt != nil {
+		t.Errorf("empty server_deleted_at decoded as %v", entry.ServerDeletedAt)
+	}
+	data, err = json.Marshal(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"at":"`+at+`"`) {
+		t.Errorf("time entry encoded as %s", data)
+	}
+
+	var detailed DetailedTimeEntry
+	if err := json.Unmarshal([]byte(`{"id":3,"start":"2026-10-17T15:00:00+02","end":null,"updated":"`+at+`"}`), &detailed); err != nil {
+		t.Fatal(err)
+	}
+	if detailed.End != nil {
+		t.Errorf("null end decoded as %v", detailed.End)
+	}
+	data, err = json.Marshal(detailed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"start":"2026-10-17T15:00:00+02"`) || !strings.Contains(string(data), `"updated":"`+at+`"`) {
+		t.Errorf("detailed time entry encoded as %s", data)
+	}
+}
+
+func FuzzTimestamp(f *testing.F) {
+	for _, seed := range []string{
+		`"2026-10-17T14:05:09Z"`,
+		`"2026-10-17T14:05:09.123456789+02:00"`,
+		`"2026-10-17 14:05:09+0200"`,
+		`"2026-10-17T14:05:09"`,
+		`"2026-10-17"`,
+		`""`,
+		`null`,
+		`"\u0000"`,
+		`12`,
+	} {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var ts Timestamp
+		if err := ts.UnmarshalJSON(data); err != nil {
+			return
+		}
+
+		encoded, err := json.Marshal(ts)
+		if err != nil {
+			// years outside of 0-9999 cannot be encoded once changed
+			return
+		}
+		var decoded Timestamp
+		if err := json.Unmarshal(encoded, &decoded); err != nil {
+			t.Fatalf("decoding %s, encoded from %q: %v", encoded, data, err)
+		}
+		if !decoded.Equal(ts.Time) {
+			t.Fatalf("%q decoded as %v, then as %v", data, ts.Time, decoded.Time)
+		}
+	})
+}
+
+func FuzzDecodeTimeEntry(f *testing.F) {
+	for _, seed := range []string{
+		`{"id":1,"start":"2026-10-17T14:05:09Z","stop":"2026-10-17T15:05:09.5+02","at":"2026-10-17 15:05:09","duration":3600}`,
+		`{"id":1,"start":null,"server_deleted_at":""}`,
+		`{"id":1,"start":"now"}`,
+	} {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var entry TimeEntry
+		_ = json.Unmarshal(data, &entry)
+		var detailed DetailedTimeEntry
+		_ = json.Unmarshal(data, &detailed)
+		var project Project
+		_ = json.Unmarshal(data, &project)
+	})
+}
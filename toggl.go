@@ -8,18 +8,23 @@ package toggl
 import (
 	"bytes"
 	"encoding/json"
+	"time"
 )
 
 // Toggl service constants
 const (
 	TogglAPI       = "https://api.track.toggl.com/api/v9"
 	ReportsAPI     = "https://api.track.toggl.com/reports/api/v2"
+	WebhooksAPI    = "https://api.track.toggl.com/webhooks/api/v1"
 	DefaultAppName = "go-toggl"
 )
 
 var (
 	// AppName is the application name used when creating timers.
 	AppName = DefaultAppName
+
+	// Epoch is the earliest date time entries can have been tracked.
+	Epoch = time.Date(2006, time.January, 1, 0, 0, 0, 0, time.UTC)
 )
 
 // Account represents a user account.
@@ -34,14 +39,18 @@ type Account struct {
 	Tags            []Tag       `json:"tags"`
 	TimeEntries     []TimeEntry `json:"time_entries"`
 	BeginningOfWeek int         `json:"beginning_of_week"`
+	DefaultWid      int         `json:"default_workspace_id"`
 }
 
 // Task represents a task.
 type Task struct {
-	Wid  int    `json:"wid"`
-	Pid  int    `json:"pid"`
-	ID   int    `json:"id"`
-	Name string `json:"name"`
+	Wid              int    `json:"workspace_id"`
+	Pid              int    `json:"project_id"`
+	ID               int    `json:"id"`
+	Name             string `json:"name"`
+	Active           bool   `json:"active"`
+	EstimatedSeconds *int   `json:"estimated_seconds,omitempty"`
+	TrackedSeconds   int    `json:"tracked_seconds"`
 }
 
 // Client represents a client.
@@ -53,6 +62,13 @@ type Client struct {
 	Notes    string `json:"notes"`
 }
 
+// User represents a member of a workspace.
+type User struct {
+	ID       int    `json:"id"`
+	Email    string `json:"email"`
+	Fullname string `json:"fullname"`
+}
+
 // Workspace represents a user workspace.
 type Workspace struct {
 	ID              int    `json:"id"`
@@ -0,0 +1,588 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/leucos/go-toggl"
+	"github.com/leucos/go-toggl/parse"
+	"github.com/leucos/go-toggl/period"
+	"github.com/leucos/go-toggl/render"
+)
+
+// command is a toggl subcommand.
+type command struct {
+	help string
+	run  func(e *env, args []string) error
+
+	// anonymous commands run without a session.
+	anonymous bool
+
+	// hidden commands are not listed.
+	hidden bool
+}
+
+var commands = map[string]command{
+	"login":    {help: "log in and store the API token", run: cmdLogin, anonymous: true},
+	"add":      {help: "add a time entry described in free text", run: cmdAdd},
+	"start":    {help: "start a new time entry", run: cmdStart},
+	"stop":     {help: "stop the running time entry", run: cmdStop},
+	"status":   {help: "show the running time entry", run: cmdStatus},
+	"continue": {help: "continue the last, or given, time entry", run: cmdContinue},
+	"list":     {help: "list recent time entries", run: cmdList},
+	"edit":     {help: "change a time entry", run: cmdEdit},
+	"delete":   {help: "delete a time entry", run: cmdDelete},
+	"projects": {help: "list projects", run: cmdProjects},
+	"clients":  {help: "list clients", run: cmdClients},
+	"tags":     {help: "list tags", run: cmdTags},
+	"report":   {help: "show a summary report", run: cmdReport},
+	"tui":      {help: "run the full-screen terminal UI", run: cmdTui},
+}
+
+func commandNames() []string {
+	names := make([]string, 0, len(commands))
+	for name, cmd := range commands {
+		if cmd.hidden {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// env holds what commands share: the session, the configuration, the
+// command flags and the workspace.
+type env struct {
+	session *toggl.Session
+	config  *config
+	flags   *flag.FlagSet
+	wid     *int
+	output  *string
+	account *toggl.Account
+}
+
+func (c command) runWith(session *toggl.Session, cfg *config, name string, args []string) error {
+	e := &env{session: session, config: cfg, flags: flag.NewFlagSet(name, flag.ContinueOnError)}
+	e.wid = e.flags.Int("w", cfg.Workspace, "workspace ID (defaults to the default workspace)")
+	e.output = e.flags.String("output", cfg.Output, "output format: table, json, yaml, csv or template=TEMPLATE")
+	return c.run(e, args)
+}
+
+// parse parses the command flags, returning the positional arguments.
+func (e *env) parse(args []string) ([]string, error) {
+	if err := e.flags.Parse(args); err != nil {
+		return nil, err
+	}
+	return e.flags.Args(), nil
+}
+
+func (e *env) getAccount() (*toggl.Account, error) {
+	if e.account == nil {
+		e.session.EnableAutoWarm()
+		account, err := e.session.GetAccount()
+		if err != nil {
+			return nil, err
+		}
+		e.account = &account
+	}
+	return e.account, nil
+}
+
+// workspace returns the workspace selected with -w, or the configured one,
+// or the default one of the account.
+func (e *env) workspace() (int, error) {
+	if *e.wid != 0 {
+		return *e.wid, nil
+	}
+
+	account, err := e.getAccount()
+	if err != nil {
+		return 0, err
+	}
+	if account.DefaultWid != 0 {
+		return account.DefaultWid, nil
+	}
+	if len(account.Workspaces) > 0 {
+		return account.Workspaces[0].ID, nil
+	}
+	return 0, errors.New("no workspace found")
+}
+
+func cmdLogin(e *env, args []string) error {
+	username := e.flags.String("u", "", "email address")
+	if _, err := e.parse(args); err != nil {
+		return err
+	}
+
+	var err error
+	if *username == "" {
+		*username, err = readLine("Email: ")
+		if err != nil {
+			return err
+		}
+	}
+
+	password, err := readPassword("Password: ")
+	if err != nil {
+		return err
+	}
+
+	session, err := toggl.NewSession(*username, password)
+	if err != nil {
+		return err
+	}
+
+	if err := e.config.storeToken(session.APIToken); err != nil {
+		return fmt.Errorf("error storing API token: %v", err)
+	}
+	fmt.Fprintln(os.Stderr, "logged in as", *username)
+	return nil
+}
+
+func cmdStart(e *env, args []string) error {
+	project := e.flags.String("p", "", "project name or ID")
+	tags := e.flags.String("t", "", "comma-separated tags")
+	billable := e.flags.Bool("b", false, "billable")
+	args, err := e.parse(args)
+	if err != nil {
+		return err
+	}
+
+	wid, err := e.workspace()
+	if err != nil {
+		return err
+	}
+
+	entry := toggl.TimeEntry{
+		Wid:         wid,
+		Description: strings.Join(args, " "),
+		Duration:    -1,
+		Billable:    *billable,
+		Tags:        splitTags(*tags),
+	}
+	if *project != "" {
+		p, err := e.findProject(wid, *project)
+		if err != nil {
+			return err
+		}
+		entry.Pid = &p.ID
+	}
+
+	if err := e.session.EnsureTimeEntryTags(entry); err != nil {
+		return err
+	}
+
+	started, err := e.session.CreateTimeEntry(entry)
+	if err != nil {
+		return err
+	}
+
+	return e.render(wid, started)
+}
+
+func cmdAdd(e *env, args []string) error {
+	args, err := e.parse(args)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return errors.New(`usage: add "2h on @project #tag yesterday 14:00 billable"`)
+	}
+
+	wid, err := e.workspace()
+	if err != nil {
+		return err
+	}
+	text := strings.Join(args, " ")
+	parsed, err := parse.Parse(text, parse.Options{Location: e.session.Location()})
+	var perr *parse.Error
+	if errors.As(err, &perr) {
+		// Pos is a byte offset, the caret goes under its character
+		column := utf8.RuneCountInString(text[:perr.Pos])
+		return fmt.Errorf("%s\n%s^ %s", text, strings.Repeat(" ", column), perr.Msg)
+	}
+	if err != nil {
+		return err
+	}
+
+	entry := parsed.TimeEntry(wid)
+	if parsed.Project != "" {
+		p, err := e.findProject(wid, parsed.Project)
+		if err != nil {
+			return err
+		}
+		entry.Pid = &p.ID
+	}
+
+	if err := e.session.EnsureTimeEntryTags(entry); err != nil {
+		return err
+	}
+
+	created, err := e.session.CreateTimeEntry(entry)
+	if err != nil {
+		return err
+	}
+
+	return e.render(wid, created)
+}
+
+func cmdStop(e *env, args []string) error {
+	if _, err := e.parse(args); err != nil {
+		return err
+	}
+
+	current, err := e.session.GetCurrentTimeEntry()
+	if err != nil {
+		return err
+	}
+	if current.ID == 0 {
+		return errors.New("no running time entry")
+	}
+
+	stopped, err := e.session.StopTimeEntry(current)
+	if err != nil {
+		return err
+	}
+
+	return e.render(stopped.Wid, stopped)
+}
+
+func cmdStatus(e *env, args []string) error {
+	if _, err := e.parse(args); err != nil {
+		return err
+	}
+
+	current, err := e.session.GetCurrentTimeEntry()
+	if err != nil {
+		return err
+	}
+	if current.ID == 0 {
+		fmt.Println("no running time entry")
+		return nil
+	}
+
+	return e.render(current.Wid, current)
+}
+
+func cmdContinue(e *env, args []string) error {
+	args, err := e.parse(args)
+	if err != nil {
+		return err
+	}
+
+	var entry toggl.TimeEntry
+	if len(args) > 0 {
+		entry, err = getEntry(e.session, args[0])
+	} else {
+		entry, err = lastEntry(e.session)
+	}
+	if err != nil {
+		return err
+	}
+
+	continued, err := e.session.ContinueTimeEntry(entry, false)
+	if err != nil {
+		return err
+	}
+
+	return e.render(continued.Wid, continued)
+}
+
+func cmdList(e *env, args []string) error {
+	days := e.flags.Int("days", 1, "number of days to list, including today")
+	spec := e.flags.String("period", "", "period to list instead of days, as for report")
+	if _, err := e.parse(args); err != nil {
+		return err
+	}
+
+	wid, err := e.workspace()
+	if err != nil {
+		return err
+	}
+
+	if *days < 1 {
+		return fmt.Errorf("invalid number of days %d", *days)
+	}
+
+	cal := period.For(e.session)
+	p := cal.LastNDays(*days)
+	if *spec != "" {
+		if p, err = cal.Parse(*spec); err != nil {
+			return err
+		}
+	}
+
+	entries, err := e.session.GetTimeEntriesIn(p)
+	if err != nil {
+		return err
+	}
+
+	var shown []toggl.TimeEntry
+	for _, entry := range entries {
+		if entry.Wid == wid {
+			shown = append(shown, entry)
+		}
+	}
+	sort.Slice(shown, func(i, j int) bool { return shown[i].StartTime().Before(shown[j].StartTime()) })
+
+	return e.render(wid, shown)
+}
+
+func cmdEdit(e *env, args []string) error {
+	description := e.flags.String("d", "", "new description")
+	project := e.flags.String("p", "", "new project name or ID")
+	tags := e.flags.String("t", "", "new comma-separated tags")
+	start := e.flags.String("start", "", "new start time (15:04, 2006-01-02 15:04 or RFC 3339)")
+	stop := e.flags.String("stop", "", "new stop time (15:04, 2006-01-02 15:04 or RFC 3339)")
+	args, err := e.parse(args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return errors.New("usage: edit [flags] id")
+	}
+
+	base, err := getEntry(e.session, args[0])
+	if err != nil {
+		return err
+	}
+	entry := base.Copy()
+
+	e.flags.Visit(func(f *flag.Flag) {
+		if err != nil {
+			return
+		}
+		switch f.Name {
+		case "d":
+			entry.Description = *description
+		case "p":
+			var p toggl.Project
+			p, err = e.findProject(entry.Wid, *project)
+			entry.Pid = &p.ID
+		case "t":
+			entry.Tags = splitTags(*tags)
+			err = e.session.EnsureTimeEntryTags(entry)
+		case "start":
+			var t time.Time
+			t, err = parseTime(*start, e.session.Now())
+			entry.SetStartTime(t, false)
+		case "stop":
+			var t time.Time
+			t, err = parseTime(*stop, e.session.Now())
+			if err == nil {
+				err = entry.SetStopTime(t)
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	updated, err := e.session.UpdateTimeEntryChecked(base, entry)
+	if err != nil {
+		return err
+	}
+
+	return e.render(updated.Wid, updated)
+}
+
+func cmdDelete(e *env, args []string) error {
+	args, err := e.parse(args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return errors.New("usage: delete id")
+	}
+
+	entry, err := getEntry(e.session, args[0])
+	if err != nil {
+		return err
+	}
+
+	_, err = e.session.DeleteTimeEntry(entry)
+	return err
+}
+
+func cmdProjects(e *env, args []string) error {
+	archived := e.flags.Bool("archived", false, "list archived projects")
+	if _, err := e.parse(args); err != nil {
+		return err
+	}
+
+	wid, err := e.workspace()
+	if err != nil {
+		return err
+	}
+
+	state := toggl.ActiveProjects
+	if *archived {
+		state = toggl.ArchivedProjects
+	}
+	projects, err := e.session.GetProjects(wid, state)
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(projects, func(i, j int) bool { return projects[i].Name < projects[j].Name })
+	return e.render(wid, projects)
+}
+
+func cmdClients(e *env, args []string) error {
+	if _, err := e.parse(args); err != nil {
+		return err
+	}
+
+	wid, err := e.workspace()
+	if err != nil {
+		return err
+	}
+
+	clients, err := e.session.GetClients(wid)
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(clients, func(i, j int) bool { return clients[i].Name < clients[j].Name })
+	return e.render(wid, clients)
+}
+
+func cmdTags(e *env, args []string) error {
+	if _, err := e.parse(args); err != nil {
+		return err
+	}
+
+	wid, err := e.workspace()
+	if err != nil {
+		return err
+	}
+
+	tags, err := e.session.GetTags(wid)
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	return e.render(wid, tags)
+}
+
+func cmdReport(e *env, args []string) error {
+	spec := e.flags.String("period", "7d", "period of the report: today, this-week, last-month, 30d, 2026-W41, 2026-Q3, 2026-09, 2026-09-01..2026-09-15...")
+	since := e.flags.String("since", "", "first day of the report, instead of a period")
+	until := e.flags.String("until", "", "last day of the report, instead of a period (defaults to today)")
+	if _, err := e.parse(args); err != nil {
+		return err
+	}
+
+	cal := period.For(e.session)
+	if *since != "" || *until != "" {
+		today := cal.Today().Start.Format("2006-01-02")
+		if *since == "" {
+			*since = today
+		}
+		if *until == "" {
+			*until = today
+		}
+		*spec = *since + ".." + *until
+	}
+	p, err := cal.Parse(*spec)
+	if err != nil {
+		return err
+	}
+
+	wid, err := e.workspace()
+	if err != nil {
+		return err
+	}
+
+	report, err := e.session.GetSummaryReportIn(wid, p)
+	if err != nil {
+		return err
+	}
+
+	return e.render(wid, report)
+}
+
+// findProject finds a project by alias, ID or name.
+func (e *env) findProject(wid int, name string) (toggl.Project, error) {
+	name = e.config.project(name)
+	if id, err := strconv.Atoi(name); err == nil {
+		return e.session.GetProject(id, wid)
+	}
+	return e.session.FindProjectByName(wid, name)
+}
+
+func getEntry(session *toggl.Session, arg string) (toggl.TimeEntry, error) {
+	id, err := strconv.Atoi(arg)
+	if err != nil {
+		return toggl.TimeEntry{}, fmt.Errorf("invalid time entry ID %q", arg)
+	}
+	return session.GetTimeEntry(id)
+}
+
+// lastEntry returns the most recent time entry of the last weeks.
+func lastEntry(session *toggl.Session) (toggl.TimeEntry, error) {
+	now := time.Now()
+	entries, err := session.GetTimeEntries(now.AddDate(0, 0, -14), now.Add(time.Minute))
+	if err != nil {
+		return toggl.TimeEntry{}, err
+	}
+	if len(entries) == 0 {
+		return toggl.TimeEntry{}, errors.New("no recent time entry")
+	}
+
+	last := entries[0]
+	for _, entry := range entries[1:] {
+		if entry.StartTime().After(last.StartTime()) {
+			last = entry
+		}
+	}
+	return last, nil
+}
+
+// splitTags splits a comma-separated list of tags. The list is empty rather
+// than nil when there are no tags, so that it clears the tags of an entry.
+func splitTags(s string) []string {
+	tags := []string{}
+	for _, t := range strings.Split(s, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// parseTime parses a time given as 15:04 (today), 2006-01-02 15:04 or RFC 3339,
+// in the time zone of now.
+func parseTime(s string, now time.Time) (time.Time, error) {
+	if t, err := time.ParseInLocation("15:04", s, now.Location()); err == nil {
+		return time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location()), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04", s, now.Location()); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", s)
+}
+
+// render writes v in the selected output format, with the project and
+// client names of the workspace wid.
+func (e *env) render(wid int, v any) error {
+	r, err := render.New(*e.output)
+	if err != nil {
+		return err
+	}
+	r.Names = render.SessionNames(e.session, wid)
+	r.Location = e.session.Location()
+	return r.Render(os.Stdout, v)
+}
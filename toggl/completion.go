@@ -0,0 +1,293 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/leucos/go-toggl"
+	"github.com/leucos/go-toggl/render"
+)
+
+// completionTTL is how long the names offered by shell completion are
+// reused before being fetched again.
+const completionTTL = 10 * time.Minute
+
+// completionDays is how far back descriptions are offered.
+const completionDays = 14
+
+func init() {
+	// registered here since __complete lists the commands
+	commands["completion"] = command{help: "print the bash, zsh or fish completion script", run: cmdCompletion, anonymous: true}
+	commands["__complete"] = command{run: cmdComplete, anonymous: true, hidden: true}
+}
+
+var completionScripts = map[string]string{
+	"bash": `# bash completion for toggl, load with: source <(toggl completion bash)
+_toggl() {
+	local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}" kind
+	if [ "$COMP_CWORD" -eq 1 ]; then
+		kind=commands
+	else
+		case "$prev" in
+		-p|--p) kind=projects ;;
+		-t|--t) kind=tags ;;
+		-output|--output) kind=outputs ;;
+		*)
+			case "${COMP_WORDS[1]}" in
+			start|add) kind=descriptions ;;
+			completion) kind=shells ;;
+			*) return ;;
+			esac ;;
+		esac
+	fi
+	local IFS=$'\n'
+	COMPREPLY=($(toggl __complete "$kind" "$cur" 2>/dev/null | while IFS= read -r word; do printf '%q\n' "$word"; done))
+}
+complete -F _toggl toggl
+`,
+	"zsh": `#compdef toggl
+# zsh completion for toggl, load with: source <(toggl completion zsh)
+_toggl() {
+	local kind
+	if (( CURRENT == 2 )); then
+		kind=commands
+	else
+		case ${words[CURRENT-1]} in
+		-p|--p) kind=projects ;;
+		-t|--t) kind=tags ;;
+		-output|--output) kind=outputs ;;
+		*)
+			case ${words[2]} in
+			start|add) kind=descriptions ;;
+			completion) kind=shells ;;
+			*) return 1 ;;
+			esac ;;
+		esac
+	fi
+	local -a candidates
+	candidates=("${(@f)$(toggl __complete $kind "${words[CURRENT]}" 2>/dev/null)}")
+	[[ -n $candidates ]] && compadd -U -- "${candidates[@]}"
+}
+compdef _toggl toggl
+`,
+	"fish": `# fish completion for toggl, load with: toggl completion fish | source
+function __toggl_complete
+	set -l tokens (commandline -opc)
+	set -l kind
+	if test (count $tokens) -eq 1
+		set kind commands
+	else
+		switch $tokens[-1]
+		case -p --p
+			set kind projects
+		case -t --t
+			set kind tags
+		case -output --output
+			set kind outputs
+		case '*'
+			switch $tokens[2]
+			case start add
+				set kind descriptions
+			case completion
+				set kind shells
+			case '*'
+				return
+			end
+		end
+	end
+	toggl __complete $kind (commandline -ct) 2>/dev/null
+end
+complete -c toggl -f -a '(__toggl_complete)'
+`,
+}
+
+func cmdCompletion(e *env, args []string) error {
+	args, err := e.parse(args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 || completionScripts[args[0]] == "" {
+		return errors.New("usage: completion bash|zsh|fish")
+	}
+
+	fmt.Print(completionScripts[args[0]])
+	return nil
+}
+
+// cmdComplete prints the candidates of a kind starting with a prefix, for
+// the completion scripts. Errors are silent so they do not garble the shell.
+func cmdComplete(e *env, args []string) error {
+	args, err := e.parse(args)
+	if err != nil || len(args) == 0 {
+		return nil
+	}
+	kind, prefix := args[0], ""
+	if len(args) > 1 {
+		prefix = args[1]
+	}
+
+	var candidates []string
+	switch kind {
+	case "commands":
+		candidates = commandNames()
+	case "outputs":
+		candidates = append(candidates, render.Formats...)
+		candidates[len(candidates)-1] += "="
+	case "shells":
+		for shell := range completionScripts {
+			candidates = append(candidates, shell)
+		}
+	case "projects", "clients", "tags", "descriptions":
+		names, err := e.completionNames()
+		if err != nil {
+			return nil
+		}
+		candidates = names.of(kind)
+		if kind == "projects" {
+			for alias := range e.config.Aliases {
+				candidates = append(candidates, alias)
+			}
+		}
+	}
+
+	// tags are comma-separated, only the last one is completed
+	head := ""
+	if kind == "tags" {
+		if i := strings.LastIndex(prefix, ","); i >= 0 {
+			head, prefix = prefix[:i+1], prefix[i+1:]
+		}
+	}
+
+	sort.Strings(candidates)
+	lower := strings.ToLower(prefix)
+	for i, c := range candidates {
+		if i > 0 && c == candidates[i-1] {
+			continue
+		}
+		if strings.HasPrefix(strings.ToLower(c), lower) {
+			fmt.Println(head + c)
+		}
+	}
+	return nil
+}
+
+// completionNames holds the names offered by completion, saved in the user
+// cache directory so that completing does not wait for Toggl.
+type completionNames struct {
+	Updated      time.Time `json:"updated"`
+	Projects     []string  `json:"projects"`
+	Clients      []string  `json:"clients"`
+	Tags         []string  `json:"tags"`
+	Descriptions []string  `json:"descriptions"`
+}
+
+func (n *completionNames) of(kind string) []string {
+	switch kind {
+	case "projects":
+		return n.Projects
+	case "clients":
+		return n.Clients
+	case "tags":
+		return n.Tags
+	default:
+		return n.Descriptions
+	}
+}
+
+// completionNames returns the saved names of the workspace, fetching them
+// again when they are too old.
+func (e *env) completionNames() (*completionNames, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, "toggl", fmt.Sprintf("completion-%d.json", *e.wid))
+
+	var names completionNames
+	if data, err := os.ReadFile(path); err == nil && json.Unmarshal(data, &names) == nil &&
+		time.Since(names.Updated) < completionTTL {
+		return &names, nil
+	}
+
+	if err := e.openSession(); err != nil {
+		return nil, err
+	}
+	// the account warms the session cache, which the getters below use
+	if _, err := e.getAccount(); err != nil {
+		return nil, err
+	}
+	wid, err := e.workspace()
+	if err != nil {
+		return nil, err
+	}
+
+	names = completionNames{Updated: time.Now()}
+	clients := map[int]string{}
+	list, err := e.session.GetClients(wid)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range list {
+		clients[c.ID] = c.Name
+		names.Clients = append(names.Clients, c.Name)
+	}
+
+	projects, err := e.session.GetProjects(wid)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range projects {
+		names.Projects = append(names.Projects, p.Name)
+		if p.Cid != nil && clients[*p.Cid] != "" {
+			names.Projects = append(names.Projects, clients[*p.Cid]+" / "+p.Name)
+		}
+	}
+
+	tags, err := e.session.GetTags(wid)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tags {
+		names.Tags = append(names.Tags, t.Name)
+	}
+
+	now := time.Now()
+	entries, err := e.session.GetTimeEntries(now.AddDate(0, 0, -completionDays), now.Add(time.Minute))
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.Wid == wid && entry.Description != "" {
+			names.Descriptions = append(names.Descriptions, entry.Description)
+		}
+	}
+
+	if data, err := json.Marshal(names); err == nil {
+		if os.MkdirAll(filepath.Dir(path), 0o700) == nil {
+			os.WriteFile(path, data, 0o600)
+		}
+	}
+
+	return &names, nil
+}
+
+// openSession opens a session for anonymous commands needing one.
+func (e *env) openSession() error {
+	if e.session != nil {
+		return nil
+	}
+
+	token, err := e.config.token()
+	if err != nil {
+		return err
+	}
+	session := toggl.OpenSession(token)
+	e.session = &session
+	return nil
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// TokenEnv is the environment variable holding the API token.
+const TokenEnv = "TOGGL_API_TOKEN"
+
+// ConfigEnv is the environment variable overriding the config file path.
+const ConfigEnv = "TOGGL_CONFIG"
+
+// config is the toggl configuration, stored as JSON in toggl/config.json
+// under the user config directory ($XDG_CONFIG_HOME, or ~/.config, or
+// %AppData% on Windows).
+type config struct {
+	// APIToken is the token stored by toggl login when there is no
+	// credential helper.
+	APIToken string `json:"api_token,omitempty"`
+
+	// CredentialHelper is a shell command storing the API token. It is run
+	// with the "get" argument to print the token, and with "store" to store
+	// the token given on its standard input.
+	CredentialHelper string `json:"credential_helper,omitempty"`
+
+	// Workspace is the default workspace, instead of the account's one.
+	Workspace int `json:"workspace,omitempty"`
+
+	// Aliases maps short names to project names or IDs.
+	Aliases map[string]string `json:"aliases,omitempty"`
+
+	// Output is the default output format.
+	Output string `json:"output,omitempty"`
+
+	path string
+}
+
+func configPath() (string, error) {
+	if path := os.Getenv(ConfigEnv); path != "" {
+		return path, nil
+	}
+
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "toggl", "config.json"), nil
+}
+
+// configDir returns the user config directory. Unlike os.UserConfigDir, it
+// is ~/.config on macOS too, as usual for command line tools.
+func configDir() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return dir, nil
+	}
+	if runtime.GOOS == "windows" {
+		return os.UserConfigDir()
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config"), nil
+}
+
+// loadConfig reads the config file. A missing file is an empty config.
+func loadConfig() (*config, error) {
+	path, err := configPath()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &config{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", path, err)
+	}
+	return cfg, nil
+}
+
+// save writes the config file, readable by the user only since it may hold
+// the API token.
+func (c *config) save() error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o700); err != nil {
+		return err
+	}
+
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
+
+// token returns the API token from the environment, the credential helper
+// or the config file, in that order.
+func (c *config) token() (string, error) {
+	if token := os.Getenv(TokenEnv); token != "" {
+		return token, nil
+	}
+
+	if c.CredentialHelper != "" {
+		out, err := c.credentialHelper("get", "")
+		if err != nil {
+			return "", err
+		}
+		if token := strings.TrimSpace(out); token != "" {
+			return token, nil
+		}
+	}
+
+	if c.APIToken != "" {
+		return c.APIToken, nil
+	}
+
+	return "", fmt.Errorf("no API token: set %s or run toggl login", TokenEnv)
+}
+
+// storeToken stores the API token with the credential helper, or in the
+// config file.
+func (c *config) storeToken(token string) error {
+	if c.CredentialHelper != "" {
+		_, err := c.credentialHelper("store", token)
+		return err
+	}
+
+	c.APIToken = token
+	return c.save()
+}
+
+func (c *config) credentialHelper(action, input string) (string, error) {
+	cmd := exec.Command("sh", "-c", c.CredentialHelper+" "+action)
+	cmd.Stdin = strings.NewReader(input)
+	cmd.Stderr = os.Stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("error running credential helper: %v", err)
+	}
+	return string(out), nil
+}
+
+// project returns the project name or ID an alias stands for.
+func (c *config) project(name string) string {
+	if alias, ok := c.Aliases[name]; ok {
+		return alias
+	}
+	return name
+}
+
+var stdin = bufio.NewReader(os.Stdin)
+
+// readLine prompts for a line on the standard input.
+func readLine(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	line, err := stdin.ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// readPassword prompts for a password, without echoing it when the standard
+// input is a terminal.
+func readPassword(prompt string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return readLine(prompt)
+	}
+
+	fmt.Fprint(os.Stderr, prompt)
+	password, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	return string(password), err
+}
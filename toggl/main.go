@@ -1,39 +1,173 @@
 /*
-The toggl command will display a user's Toggl account information.
+The toggl command tracks time with Toggl from the command line.
 
 Usage:
 
-	toggl API_TOKEN
+	toggl [command [arguments]]
 
-The API token can be retrieved from a user's account information page at toggl.com.
+Without a command, the user's Toggl account information is displayed. The
+commands are:
+
+	login [-u email]
+	add text
+	start [-p project] [-t tags] [-b] description
+	stop
+	status
+	continue [id]
+	list [-days n] [-period period]
+	edit [-d description] [-p project] [-t tags] [-start time] [-stop time] id
+	delete id
+	projects [-archived]
+	clients
+	tags
+	report [-period period] [-since date] [-until date]
+	tui [-interval duration]
+	completion bash|zsh|fish
+
+Every command accepts a -w flag selecting the workspace, which defaults to the
+configured workspace, or to the user's default workspace, and an -output flag
+selecting the output format: table (the default), json, yaml, csv or
+template=TEMPLATE, where TEMPLATE is a Go template. Projects can be
+given by name, optionally prefixed with their client name as in
+"Acme / Website". Run "toggl command -h" for the flags of a command.
+
+Periods are given as today, yesterday, this-week, last-week, this-month,
+last-month, this-quarter, this-year, last-year, a number of days such as 30d,
+an ISO week (2026-W41), a quarter (2026-Q3), a month (2026-09), a year, a day,
+or a range of days (2026-09-01..2026-09-15). Days and weeks follow the time
+zone and first day of the week of the account.
+
+The add command creates a time entry described in free text, such as
+"2h on @website #design yesterday 14:00 billable", in the account's time zone.
+See the parse package for the syntax.
+
+The tui command runs a full-screen terminal UI showing the running time entry,
+today's entries and the totals of the week. Its keyboard shortcuts start,
+stop and continue entries, pick projects with fuzzy search, and edit the
+descriptions and tags of the selected entry.
+
+The completion command prints a shell completion script, to be loaded with
+"source <(toggl completion bash)" in bash or zsh, or with
+"toggl completion fish | source" in fish. Commands, output formats, project,
+client and tag names, and recent descriptions are completed. The names are
+saved in the user cache directory and fetched again after ten minutes.
+
+The API token is read from the TOGGL_API_TOKEN environment variable, from
+the credential helper or from the configuration file, in that order. The
+login command asks for the user's email and password, then stores the token
+with the credential helper or in the configuration file. The token can also
+be retrieved from a user's account information page at toggl.com.
+
+The configuration file is toggl/config.json in the user configuration
+directory ($XDG_CONFIG_HOME or ~/.config, %AppData% on Windows), or the file
+given by the TOGGL_CONFIG environment variable:
+
+	{
+		"credential_helper": "pass toggl",
+		"workspace": 123456,
+		"aliases": {"web": "Acme / Website"},
+		"output": "json"
+	}
+
+The credential helper is a shell command run with the "get" argument to
+print the token, and with "store" to store the token read from its standard
+input. Aliases can be used wherever a project is expected, and the output format is
+the default of the -output flag.
 */
 package main
 
 import (
-	"encoding/json"
+	"encoding/hex"
+	"fmt"
 	"os"
 
 	"github.com/leucos/go-toggl"
+	"github.com/leucos/go-toggl/render"
 )
 
 func main() {
-	if len(os.Args) != 2 || os.Args[1] == "-h" || os.Args[1] == "--help" {
-		println("usage:", os.Args[0], "API_TOKEN")
+	args := os.Args[1:]
+	if len(args) > 0 && (args[0] == "-h" || args[0] == "--help") {
+		usage()
 		return
 	}
 
-	session := toggl.OpenSession(os.Args[1])
-
-	account, err := session.GetAccount()
+	cfg, err := loadConfig()
 	if err != nil {
-		println(err.Error())
-		return
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	// the API token used to be the first argument
+	var token string
+	if len(args) > 0 && isToken(args[0]) {
+		fmt.Fprintf(os.Stderr, "warning: giving the API token as an argument is deprecated, set %s or run toggl login\n", TokenEnv)
+		token, args = args[0], args[1:]
+	}
+
+	cmd := command{run: showAccount}
+	name := "toggl"
+	if len(args) > 0 {
+		var ok bool
+		name, args = args[0], args[1:]
+		if cmd, ok = commands[name]; !ok {
+			fmt.Fprintf(os.Stderr, "unknown command %q\n", name)
+			usage()
+			os.Exit(2)
+		}
 	}
 
-	data, err := json.MarshalIndent(&account, "", "    ")
+	var session *toggl.Session
+	if !cmd.anonymous {
+		if token == "" {
+			token, err = cfg.token()
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		s := toggl.OpenSession(token)
+		session = &s
+	}
+
+	if err := cmd.runWith(session, cfg, name, args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// isToken reports whether arg looks like an API token rather than a command.
+func isToken(arg string) bool {
+	if _, ok := commands[arg]; ok || len(arg) != 32 {
+		return false
+	}
+	_, err := hex.DecodeString(arg)
+	return err == nil
+}
+
+func usage() {
+	println("usage:", os.Args[0], "[command [arguments]]")
+	println()
+	println("commands:")
+	for _, name := range commandNames() {
+		println("  ", name, "-", commands[name].help)
+	}
+}
+
+// showAccount shows the account, as JSON unless another output format is
+// selected.
+func showAccount(e *env, args []string) error {
+	if _, err := e.parse(args); err != nil {
+		return err
+	}
+
+	account, err := e.session.GetAccount()
 	if err != nil {
-		println(err.Error())
-		return
+		return err
+	}
+
+	if *e.output == "" {
+		*e.output = render.JSON
 	}
-	println("account:", string(data))
+	return e.render(account.DefaultWid, account)
 }
@@ -0,0 +1,605 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/leucos/go-toggl"
+	"github.com/leucos/go-toggl/render"
+	"golang.org/x/term"
+)
+
+// tuiHelp lists the keyboard shortcuts of the terminal UI.
+const tuiHelp = "[s]tart  [x] stop  [c]ontinue  [p]roject  [e]dit  [t]ags  [r]efresh  [q]uit"
+
+// tui is the full-screen terminal UI of toggl tui.
+type tui struct {
+	e     *env
+	wid   int
+	names render.Names
+	keys  chan string
+	width int
+
+	running  toggl.TimeEntry
+	today    []toggl.TimeEntry
+	week     []toggl.TimeEntry
+	weekDays []time.Time
+	selected int
+	status   string
+}
+
+func cmdTui(e *env, args []string) error {
+	interval := e.flags.Duration("interval", 10*time.Second, "how often the running timer is polled")
+	if _, err := e.parse(args); err != nil {
+		return err
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return errors.New("tui needs a terminal")
+	}
+
+	wid, err := e.workspace()
+	if err != nil {
+		return err
+	}
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(fd, state)
+
+	// alternate screen, hidden cursor
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	defer fmt.Print("\x1b[?25h\x1b[?1049l")
+
+	t := &tui{e: e, wid: wid, names: render.SessionNames(e.session, wid), keys: make(chan string)}
+	go t.readKeys()
+	return t.run(*interval)
+}
+
+// readKeys sends the keys typed on the standard input, arrows and other
+// special keys being named.
+func (t *tui) readKeys() {
+	buf := make([]byte, 64)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			close(t.keys)
+			return
+		}
+
+		in := string(buf[:n])
+		switch in {
+		case "\x1b[A", "\x1bOA":
+			t.keys <- "up"
+			continue
+		case "\x1b[B", "\x1bOB":
+			t.keys <- "down"
+			continue
+		case "\x1b":
+			t.keys <- "esc"
+			continue
+		}
+		if strings.HasPrefix(in, "\x1b") {
+			// other escape sequences are ignored
+			continue
+		}
+
+		for _, r := range in {
+			switch r {
+			case '\r', '\n':
+				t.keys <- "enter"
+			case 127, 8:
+				t.keys <- "backspace"
+			case 3:
+				t.keys <- "ctrl-c"
+			default:
+				t.keys <- string(r)
+			}
+		}
+	}
+}
+
+func (t *tui) run(interval time.Duration) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := t.e.session.WatchCurrent(ctx, interval)
+
+	t.refresh()
+	tick := time.NewTicker(time.Second)
+	defer tick.Stop()
+
+	for {
+		t.draw(nil)
+
+		select {
+		case <-tick.C:
+		case event := <-events:
+			if event.Kind == toggl.WatchFailed {
+				t.status = event.Err.Error()
+			} else {
+				t.refresh()
+			}
+		case key, ok := <-t.keys:
+			if !ok {
+				return nil
+			}
+			if quit := t.handle(key); quit {
+				return nil
+			}
+		}
+	}
+}
+
+// handle runs the action of a key, reporting whether to quit.
+func (t *tui) handle(key string) bool {
+	var err error
+	t.status = ""
+
+	switch key {
+	case "q", "ctrl-c":
+		return true
+	case "up", "k":
+		if t.selected > 0 {
+			t.selected--
+		}
+	case "down", "j":
+		if t.selected < len(t.today)-1 {
+			t.selected++
+		}
+	case "r":
+		t.refresh()
+	case "s":
+		err = t.start()
+	case "x":
+		err = t.stop()
+	case "c", "enter":
+		err = t.continueSelected()
+	case "p":
+		err = t.changeProject()
+	case "e":
+		err = t.editDescription()
+	case "t":
+		err = t.editTags()
+	}
+
+	if err != nil {
+		t.status = err.Error()
+	}
+	return false
+}
+
+// refresh fetches the running time entry and the entries of the week.
+func (t *tui) refresh() {
+	running, err := t.e.session.GetCurrentTimeEntry()
+	if err != nil {
+		t.status = err.Error()
+		return
+	}
+	t.running = running
+
+	start := t.e.session.StartOfWeek(time.Now())
+	entries, err := t.e.session.GetTimeEntries(start, time.Now().Add(time.Minute))
+	if err != nil {
+		t.status = err.Error()
+		return
+	}
+
+	t.week = t.week[:0]
+	for _, entry := range entries {
+		if entry.Wid == t.wid {
+			t.week = append(t.week, entry)
+		}
+	}
+	sort.Slice(t.week, func(i, j int) bool { return t.week[i].StartTime().After(t.week[j].StartTime()) })
+
+	t.weekDays = t.weekDays[:0]
+	for day := 0; day < 7; day++ {
+		t.weekDays = append(t.weekDays, start.AddDate(0, 0, day))
+	}
+
+	today := t.e.session.StartOfDay(time.Now())
+	t.today = t.today[:0]
+	for _, entry := range t.week {
+		if !entry.StartTime().Before(today) {
+			t.today = append(t.today, entry)
+		}
+	}
+	if t.selected >= len(t.today) {
+		t.selected = max(len(t.today)-1, 0)
+	}
+}
+
+// selectedEntry returns the selected entry of today.
+func (t *tui) selectedEntry() (toggl.TimeEntry, error) {
+	if t.selected >= len(t.today) {
+		return toggl.TimeEntry{}, errors.New("no time entry selected")
+	}
+	return t.today[t.selected], nil
+}
+
+func (t *tui) start() error {
+	description, ok := t.prompt("Description: ", "")
+	if !ok {
+		return nil
+	}
+
+	entry := toggl.TimeEntry{Wid: t.wid, Description: description, Duration: -1}
+	project, ok, err := t.pickProject()
+	if err != nil {
+		return err
+	}
+	if ok {
+		entry.Pid = &project.ID
+	}
+
+	if _, err := t.e.session.CreateTimeEntry(entry); err != nil {
+		return err
+	}
+	t.selected = 0
+	t.refresh()
+	return nil
+}
+
+func (t *tui) stop() error {
+	if t.running.ID == 0 {
+		return errors.New("no running time entry")
+	}
+	if _, err := t.e.session.StopTimeEntry(t.running); err != nil {
+		return err
+	}
+	t.refresh()
+	return nil
+}
+
+func (t *tui) continueSelected() error {
+	entry, err := t.selectedEntry()
+	if err != nil {
+		return err
+	}
+	if _, err := t.e.session.ContinueTimeEntry(entry, false); err != nil {
+		return err
+	}
+	t.selected = 0
+	t.refresh()
+	return nil
+}
+
+func (t *tui) changeProject() error {
+	base, err := t.selectedEntry()
+	if err != nil {
+		return err
+	}
+
+	project, ok, err := t.pickProject()
+	if err != nil || !ok {
+		return err
+	}
+
+	entry := base.Copy()
+	entry.Pid = &project.ID
+	entry.Tid = nil
+	return t.update(base, entry)
+}
+
+func (t *tui) editDescription() error {
+	base, err := t.selectedEntry()
+	if err != nil {
+		return err
+	}
+
+	description, ok := t.prompt("Description: ", base.Description)
+	if !ok {
+		return nil
+	}
+
+	entry := base.Copy()
+	entry.Description = description
+	return t.update(base, entry)
+}
+
+func (t *tui) editTags() error {
+	base, err := t.selectedEntry()
+	if err != nil {
+		return err
+	}
+
+	tags, ok := t.prompt("Tags: ", strings.Join(base.Tags, ", "))
+	if !ok {
+		return nil
+	}
+
+	entry := base.Copy()
+	entry.Tags = splitTags(tags)
+	if err := t.e.session.EnsureTimeEntryTags(entry); err != nil {
+		return err
+	}
+	return t.update(base, entry)
+}
+
+func (t *tui) update(base, entry toggl.TimeEntry) error {
+	if _, err := t.e.session.UpdateTimeEntryChecked(base, entry); err != nil {
+		return err
+	}
+	t.refresh()
+	return nil
+}
+
+// prompt reads a line on the bottom of the screen, reporting false when
+// cancelled with escape.
+func (t *tui) prompt(label, value string) (string, bool) {
+	for {
+		t.draw([]string{label + value + "_"})
+
+		key, ok := <-t.keys
+		if !ok {
+			return "", false
+		}
+		switch key {
+		case "enter":
+			return strings.TrimSpace(value), true
+		case "esc", "ctrl-c":
+			return "", false
+		case "backspace":
+			if value != "" {
+				_, size := utf8.DecodeLastRuneInString(value)
+				value = value[:len(value)-size]
+			}
+		case "up", "down":
+		default:
+			value += key
+		}
+	}
+}
+
+// pickProject lets the user choose an active project with fuzzy search,
+// reporting false when cancelled with escape.
+func (t *tui) pickProject() (toggl.Project, bool, error) {
+	projects, err := t.e.session.GetProjects(t.wid)
+	if err != nil {
+		return toggl.Project{}, false, err
+	}
+
+	labels := make([]string, len(projects))
+	for i, p := range projects {
+		labels[i] = p.Name
+		if p.Cid != nil {
+			if client := t.names.Client(*p.Cid); client != "" {
+				labels[i] = client + " / " + p.Name
+			}
+		}
+	}
+
+	query, selected := "", 0
+	for {
+		matches := fuzzyFind(query, labels)
+		if selected >= len(matches) {
+			selected = max(len(matches)-1, 0)
+		}
+
+		lines := []string{"Project: " + query + "_"}
+		for i, m := range matches {
+			if i == 8 {
+				lines = append(lines, fmt.Sprintf("  ... %d more", len(matches)-i))
+				break
+			}
+			cursor := "  "
+			if i == selected {
+				cursor = "> "
+			}
+			lines = append(lines, cursor+labels[m])
+		}
+		t.draw(lines)
+
+		key, ok := <-t.keys
+		if !ok {
+			return toggl.Project{}, false, nil
+		}
+		switch key {
+		case "enter":
+			if len(matches) == 0 {
+				return toggl.Project{}, false, nil
+			}
+			return projects[matches[selected]], true, nil
+		case "esc", "ctrl-c":
+			return toggl.Project{}, false, nil
+		case "up":
+			if selected > 0 {
+				selected--
+			}
+		case "down":
+			selected++
+		case "backspace":
+			if query != "" {
+				_, size := utf8.DecodeLastRuneInString(query)
+				query = query[:len(query)-size]
+			}
+		default:
+			query += key
+		}
+	}
+}
+
+// fuzzyFind returns the indexes of the labels matching query, best first.
+// The letters of the query have to appear in order in a label; prefixes
+// rank first, then substrings, then the most compact matches.
+func fuzzyFind(query string, labels []string) []int {
+	type match struct{ index, score int }
+
+	query = strings.ToLower(query)
+	var matches []match
+	for i, label := range labels {
+		if score, ok := fuzzyScore(query, strings.ToLower(label)); ok {
+			matches = append(matches, match{i, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score < matches[j].score })
+
+	indexes := make([]int, len(matches))
+	for i, m := range matches {
+		indexes[i] = m.index
+	}
+	return indexes
+}
+
+// fuzzyScore scores how well s matches query, lower being better.
+func fuzzyScore(query, s string) (int, bool) {
+	switch {
+	case query == "":
+		return 0, true
+	case strings.HasPrefix(s, query):
+		return 0, true
+	case strings.Contains(s, query):
+		return 1, true
+	}
+
+	// spread of the subsequence, starting from the first matching rune
+	start, pos := -1, 0
+	rest := query
+	for i, r := range s {
+		q, size := utf8.DecodeRuneInString(rest)
+		if r != q {
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+		pos = i
+		if rest = rest[size:]; rest == "" {
+			return 2 + pos - start, true
+		}
+	}
+	return 0, false
+}
+
+// draw redraws the screen, with extra lines at the bottom.
+func (t *tui) draw(extra []string) {
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		width, height = 80, 24
+	}
+	t.width = width
+	loc := t.e.session.Location()
+
+	var lines []string
+	add := func(format string, args ...any) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}
+
+	if t.running.ID != 0 {
+		add("\x1b[1m> %s  %s\x1b[0m", render.Duration(t.elapsed(t.running)), t.describe(t.running))
+	} else {
+		add("No running time entry")
+	}
+	add("")
+
+	var total int64
+	for _, entry := range t.today {
+		total += t.elapsed(entry)
+	}
+	add("\x1b[1mToday\x1b[0m  %s", render.Duration(total))
+
+	// keep room for the header, week, help, status and extra lines
+	room := height - len(lines) - 6 - len(extra)
+	first := 0
+	if t.selected >= room && room > 0 {
+		first = t.selected - room + 1
+	}
+	for i := first; i < len(t.today) && i-first < room; i++ {
+		entry := t.today[i]
+		cursor := "  "
+		if i == t.selected {
+			cursor = "\x1b[7m>"
+		}
+		stop := "     "
+		if !entry.IsRunning() {
+			stop = entry.StopTime().In(loc).Format("15:04")
+		}
+		add("%s %s-%s  %s  %s\x1b[0m", cursor, entry.StartTime().In(loc).Format("15:04"), stop,
+			render.Duration(t.elapsed(entry)), t.describe(entry))
+	}
+	add("")
+
+	days := make([]string, 0, len(t.weekDays)+1)
+	var week int64
+	for _, day := range t.weekDays {
+		var sum int64
+		for _, entry := range t.week {
+			start := entry.StartTime().In(loc)
+			if !start.Before(day) && start.Before(day.AddDate(0, 0, 1)) {
+				sum += t.elapsed(entry)
+			}
+		}
+		week += sum
+		days = append(days, day.Format("Mon")+" "+render.Duration(sum))
+	}
+	add("\x1b[1mWeek\x1b[0m  %s  Total %s", strings.Join(days, "  "), render.Duration(week))
+	add("")
+	add("%s", tuiHelp)
+	add("%s", t.status)
+	lines = append(lines, extra...)
+
+	var b strings.Builder
+	b.WriteString("\x1b[H\x1b[2J")
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteString("\r\n")
+		}
+		b.WriteString(t.clip(line))
+	}
+	fmt.Print(b.String())
+}
+
+// clip cuts a line to the screen width, ignoring escape sequences.
+func (t *tui) clip(line string) string {
+	var b strings.Builder
+	visible, escape := 0, false
+	for _, r := range line {
+		switch {
+		case r == '\x1b':
+			escape = true
+		case escape:
+			escape = !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+		case visible >= t.width:
+			continue
+		default:
+			visible++
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// elapsed returns the duration of an entry in seconds, so far if it runs.
+func (t *tui) elapsed(entry toggl.TimeEntry) int64 {
+	if entry.IsRunning() {
+		return int64(time.Since(entry.StartTime()) / time.Second)
+	}
+	return entry.Duration
+}
+
+func (t *tui) describe(entry toggl.TimeEntry) string {
+	parts := []string{entry.Description}
+	if entry.Description == "" {
+		parts[0] = "(no description)"
+	}
+	if entry.Pid != nil {
+		project := t.names.Project(*entry.Pid)
+		if project == "" {
+			project = fmt.Sprint(*entry.Pid)
+		}
+		parts = append(parts, "@"+project)
+	}
+	for _, tag := range entry.Tags {
+		parts = append(parts, "#"+tag)
+	}
+	return strings.Join(parts, "  ")
+}
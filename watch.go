@@ -0,0 +1,255 @@
+package toggl
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// CurrentEventKind is the kind of change reported by WatchCurrent.
+type CurrentEventKind int
+
+// Changes of the running time entry
+const (
+	// TimerStarted is sent when a time entry starts running, and to new
+	// subscribers when a time entry is already running.
+	TimerStarted CurrentEventKind = iota
+	// TimerStopped is sent when the running time entry stops.
+	TimerStopped
+	// TimerSwitched is sent when another time entry replaces the running one.
+	TimerSwitched
+	DescriptionChanged
+	ProjectChanged
+	TagsChanged
+	// WatchFailed is sent when the running time entry cannot be fetched
+	// anymore.
+	WatchFailed
+)
+
+var currentEventNames = map[CurrentEventKind]string{
+	TimerStarted:       "started",
+	TimerStopped:       "stopped",
+	TimerSwitched:      "switched",
+	DescriptionChanged: "description changed",
+	ProjectChanged:     "project changed",
+	TagsChanged:        "tags changed",
+	WatchFailed:        "failed",
+}
+
+func (k CurrentEventKind) String() string {
+	return currentEventNames[k]
+}
+
+// CurrentEvent is a change of the running time entry. Previous and Current
+// have a zero ID when no time entry was, or is, running.
+type CurrentEvent struct {
+	Kind     CurrentEventKind
+	Previous TimeEntry
+	Current  TimeEntry
+	Err      error
+}
+
+// defaultWatchInterval is the polling interval of subscribers asking for
+// none.
+const defaultWatchInterval = 10 * time.Second
+
+// maxWatchBackoff bounds how much slower than requested the running entry is
+// polled while it does not change.
+const maxWatchBackoff = 4
+
+// currentWatcher polls the running time entry once for all the subscribers of
+// a session.
+type currentWatcher struct {
+	mutex   sync.Mutex
+	subs    map[*watchSub]bool
+	running bool
+	failing bool
+	last    TimeEntry
+	wake    chan struct{}
+}
+
+type watchSub struct {
+	ch       chan CurrentEvent
+	interval time.Duration
+	primed   bool
+
+	// mutex guards the channel against sends after it is closed
+	mutex  sync.Mutex
+	closed bool
+}
+
+func newCurrentWatcher() *currentWatcher {
+	return &currentWatcher{subs: make(map[*watchSub]bool), wake: make(chan struct{}, 1)}
+}
+
+// watcherMutex guards the creation of the watcher of sessions that were not
+// opened with OpenSession or NewSession.
+var watcherMutex sync.Mutex
+
+func (session *Session) getWatcher() *currentWatcher {
+	watcherMutex.Lock()
+	defer watcherMutex.Unlock()
+
+	if session.watcher == nil {
+		session.watcher = newCurrentWatcher()
+	}
+	return session.watcher
+}
+
+// WatchCurrent returns a channel receiving the changes of the running time
+// entry, polled every interval. The polling slows down, up to four times
+// the interval, while nothing changes. All the subscribers of a session
+// share the same polling, done at the shortest interval requested; a zero or
+// negative interval stands for ten seconds. Events are dropped for
+// subscribers that do not keep up. The channel is closed when ctx is done.
+func (session *Session) WatchCurrent(ctx context.Context, interval time.Duration) <-chan CurrentEvent {
+	w := session.getWatcher()
+
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+	sub := &watchSub{ch: make(chan CurrentEvent, 16), interval: interval}
+
+	w.mutex.Lock()
+	w.subs[sub] = true
+	if !w.running {
+		w.running = true
+		go session.pollCurrent(w)
+	}
+	w.mutex.Unlock()
+
+	// poll right away so the new subscriber gets the current state
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+
+	go func() {
+		<-ctx.Done()
+		w.mutex.Lock()
+		delete(w.subs, sub)
+		w.mutex.Unlock()
+
+		sub.mutex.Lock()
+		sub.closed = true
+		close(sub.ch)
+		sub.mutex.Unlock()
+	}()
+
+	return sub.ch
+}
+
+// pollCurrent polls the running time entry while there are subscribers.
+func (session *Session) pollCurrent(w *currentWatcher) {
+	idle := 0
+	for {
+		w.mutex.Lock()
+		interval := time.Duration(0)
+		for sub := range w.subs {
+			if interval == 0 || sub.interval < interval {
+				interval = sub.interval
+			}
+		}
+		if len(w.subs) == 0 {
+			w.running = false
+			w.mutex.Unlock()
+			return
+		}
+		w.mutex.Unlock()
+
+		current, err := session.GetCurrentTimeEntry()
+
+		w.mutex.Lock()
+		var events []CurrentEvent
+		if err != nil {
+			// only report the first of consecutive failures
+			if !w.failing {
+				events = []CurrentEvent{{Kind: WatchFailed, Previous: w.last, Current: w.last, Err: err}}
+			}
+			w.failing = true
+		} else {
+			w.failing = false
+			events = currentEvents(w.last, current)
+			w.last = current
+		}
+		deliveries := make(map[*watchSub][]CurrentEvent, len(w.subs))
+		for sub := range w.subs {
+			if !sub.primed && err == nil {
+				sub.primed = true
+				if current.ID != 0 && len(events) == 0 {
+					deliveries[sub] = []CurrentEvent{{Kind: TimerStarted, Current: current}}
+				}
+			}
+			deliveries[sub] = append(deliveries[sub], events...)
+		}
+		w.mutex.Unlock()
+
+		for sub, subEvents := range deliveries {
+			for _, e := range subEvents {
+				if !sub.send(e) {
+					session.logger.Debug("dropping watch event", "event", e.Kind)
+				}
+			}
+		}
+
+		if len(events) > 0 {
+			idle = 0
+		} else if idle < maxWatchBackoff-1 {
+			idle++
+		}
+
+		select {
+		case <-time.After(interval * time.Duration(idle+1)):
+		case <-w.wake:
+		}
+	}
+}
+
+// send delivers an event without blocking, reporting whether it was
+// delivered. The event is dropped if the subscriber is gone or its channel
+// is full.
+func (sub *watchSub) send(e CurrentEvent) bool {
+	sub.mutex.Lock()
+	defer sub.mutex.Unlock()
+
+	if sub.closed {
+		return false
+	}
+	select {
+	case sub.ch <- e:
+		return true
+	default:
+		return false
+	}
+}
+
+// currentEvents returns the changes from the running entry prev to cur.
+func currentEvents(prev, cur TimeEntry) []CurrentEvent {
+	event := func(kind CurrentEventKind) CurrentEvent {
+		return CurrentEvent{Kind: kind, Previous: prev, Current: cur}
+	}
+
+	switch {
+	case prev.ID == 0 && cur.ID == 0:
+		return nil
+	case prev.ID == 0:
+		return []CurrentEvent{event(TimerStarted)}
+	case cur.ID == 0:
+		return []CurrentEvent{event(TimerStopped)}
+	case prev.ID != cur.ID:
+		return []CurrentEvent{event(TimerSwitched)}
+	}
+
+	var events []CurrentEvent
+	if prev.Description != cur.Description {
+		events = append(events, event(DescriptionChanged))
+	}
+	if !equalIntPtr(prev.Pid, cur.Pid) || !equalIntPtr(prev.Tid, cur.Tid) {
+		events = append(events, event(ProjectChanged))
+	}
+	if !equalTags(prev.Tags, cur.Tags) {
+		events = append(events, event(TagsChanged))
+	}
+
+	return events
+}
@@ -0,0 +1,112 @@
+package toggl
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// WebhookEventFilter selects the events sent to a webhook subscription.
+// Entity is e.g. "time_entry" or "project", Action is "created", "updated",
+// "deleted" or "*" for all of them.
+type WebhookEventFilter struct {
+	Entity string `json:"entity"`
+	Action string `json:"action"`
+}
+
+// WebhookSubscription represents a webhook subscription of a workspace.
+type WebhookSubscription struct {
+	ID               int                  `json:"subscription_id,omitempty"`
+	Wid              int                  `json:"workspace_id,omitempty"`
+	UserID           int                  `json:"user_id,omitempty"`
+	Description      string               `json:"description"`
+	URLCallback      string               `json:"url_callback"`
+	EventFilters     []WebhookEventFilter `json:"event_filters"`
+	Enabled          bool                 `json:"enabled"`
+	Secret           string               `json:"secret,omitempty"`
+	ValidatedAt      *time.Time           `json:"validated_at,omitempty"`
+	HasPendingEvents bool                 `json:"has_pending_events,omitempty"`
+	CreatedAt        *time.Time           `json:"created_at,omitempty"`
+	UpdatedAt        *time.Time           `json:"updated_at,omitempty"`
+}
+
+// GetWebhookSubscriptions returns the webhook subscriptions of a workspace.
+func (session *Session) GetWebhookSubscriptions(wid int) ([]WebhookSubscription, error) {
+	session.logger.Debug("getting webhook subscriptions", "workspaceID", wid)
+
+	data, err := session.get(WebhooksAPI, fmt.Sprintf("/subscriptions/%d", wid), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	subs := make([]WebhookSubscription, 0)
+	err = json.Unmarshal(data, &subs)
+	if err != nil {
+		return nil, err
+	}
+
+	return subs, nil
+}
+
+// CreateWebhookSubscription creates a webhook subscription in the workspace
+// given by sub.Wid. Toggl then sends a validation event to the callback URL,
+// which is answered by webhook.Handler.
+func (session *Session) CreateWebhookSubscription(sub WebhookSubscription) (WebhookSubscription, error) {
+	session.logger.Debug("creating webhook subscription", "url", sub.URLCallback)
+	return handleWebhookSubscriptionResponse(
+		session.post(WebhooksAPI, fmt.Sprintf("/subscriptions/%d", sub.Wid), sub),
+	)
+}
+
+// UpdateWebhookSubscription changes an existing webhook subscription.
+func (session *Session) UpdateWebhookSubscription(sub WebhookSubscription) (WebhookSubscription, error) {
+	session.logger.Debug("updating webhook subscription", "subscriptionID", sub.ID)
+	return handleWebhookSubscriptionResponse(
+		session.put(WebhooksAPI, fmt.Sprintf("/subscriptions/%d/%d", sub.Wid, sub.ID), sub),
+	)
+}
+
+// EnableWebhookSubscription enables or disables a webhook subscription.
+func (session *Session) EnableWebhookSubscription(sub WebhookSubscription, enabled bool) (WebhookSubscription, error) {
+	session.logger.Debug("enabling webhook subscription", "subscriptionID", sub.ID, "enabled", enabled)
+	return handleWebhookSubscriptionResponse(
+		session.patch(WebhooksAPI, fmt.Sprintf("/subscriptions/%d/%d", sub.Wid, sub.ID), map[string]bool{"enabled": enabled}),
+	)
+}
+
+// PingWebhookSubscription asks Toggl to send a ping event to the callback URL
+// of a subscription.
+func (session *Session) PingWebhookSubscription(sub WebhookSubscription) error {
+	session.logger.Debug("pinging webhook subscription", "subscriptionID", sub.ID)
+	_, err := session.post(WebhooksAPI, fmt.Sprintf("/ping/%d/%d", sub.Wid, sub.ID), nil)
+	return err
+}
+
+// ValidateWebhookSubscription validates a subscription with the code sent in
+// its validation event, for callbacks that could not answer it directly.
+func (session *Session) ValidateWebhookSubscription(sub WebhookSubscription, code string) error {
+	session.logger.Debug("validating webhook subscription", "subscriptionID", sub.ID)
+	_, err := session.get(WebhooksAPI, fmt.Sprintf("/validate/%d/%d/%s", sub.Wid, sub.ID, code), nil)
+	return err
+}
+
+// DeleteWebhookSubscription deletes a webhook subscription.
+func (session *Session) DeleteWebhookSubscription(sub WebhookSubscription) error {
+	session.logger.Debug("deleting webhook subscription", "subscriptionID", sub.ID)
+	_, err := session.delete(WebhooksAPI, fmt.Sprintf("/subscriptions/%d/%d", sub.Wid, sub.ID))
+	return err
+}
+
+func handleWebhookSubscriptionResponse(data []byte, err error) (WebhookSubscription, error) {
+	if err != nil {
+		return WebhookSubscription{}, err
+	}
+
+	var sub WebhookSubscription
+	err = json.Unmarshal(data, &sub)
+	if err != nil {
+		return WebhookSubscription{}, err
+	}
+
+	return sub, nil
+}
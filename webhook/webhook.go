@@ -0,0 +1,220 @@
+/*
+Package webhook receives Toggl webhook events.
+
+A Handler verifies the signature of the events sent by Toggl, answers the
+validation and ping events, decodes the other events and dispatches them to
+the functions registered for their entity and action:
+
+	h, err := webhook.NewHandler(secret)
+	if err != nil {
+		log.Fatal(err)
+	}
+	h.OnTimeEntry(webhook.Created, func(e webhook.TimeEntryEvent) {
+		log.Printf("%s started %q", e.Metadata.EventUserID, e.TimeEntry.Description)
+	})
+	http.Handle("/toggl", h)
+*/
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/leucos/go-toggl"
+)
+
+// SignatureHeader is the header holding the HMAC-SHA256 signature of events.
+const SignatureHeader = "X-Webhook-Signature-256"
+
+// Entities
+const (
+	TimeEntry = "time_entry"
+	Project   = "project"
+)
+
+// Actions. Any matches all the actions when registering handlers.
+const (
+	Created = "created"
+	Updated = "updated"
+	Deleted = "deleted"
+	Any     = "*"
+)
+
+// maxEventSize bounds the size of accepted events.
+const maxEventSize = 1 << 20
+
+// Event is a webhook event sent by Toggl.
+type Event struct {
+	ID             int64           `json:"event_id"`
+	CreatedAt      time.Time       `json:"created_at"`
+	CreatorID      int             `json:"creator_id"`
+	Metadata       Metadata        `json:"metadata"`
+	Payload        json.RawMessage `json:"payload"`
+	SubscriptionID int             `json:"subscription_id"`
+	Timestamp      time.Time       `json:"timestamp"`
+	URLCallback    string          `json:"url_callback"`
+	ValidationCode string          `json:"validation_code,omitempty"`
+}
+
+// Metadata describes the change that triggered an event.
+type Metadata struct {
+	Action      string `json:"action"`
+	Model       string `json:"model"`
+	EventUserID string `json:"event_user_id"`
+	WorkspaceID string `json:"workspace_id"`
+	Path        string `json:"path"`
+	RequestType string `json:"request_type"`
+}
+
+// IsPing reports whether the event is a ping.
+func (e Event) IsPing() bool {
+	var s string
+	return json.Unmarshal(e.Payload, &s) == nil && s == "ping"
+}
+
+// TimeEntryEvent is an event about a time entry.
+type TimeEntryEvent struct {
+	Event
+	TimeEntry toggl.TimeEntry
+}
+
+// ProjectEvent is an event about a project.
+type ProjectEvent struct {
+	Event
+	Project toggl.Project
+}
+
+// Handler is an http.Handler receiving webhook events.
+type Handler struct {
+	// ErrorLog receives the events that could not be decoded for their
+	// handlers. It is ignored if nil.
+	ErrorLog func(e Event, err error)
+
+	secret   []byte
+	mutex    sync.RWMutex
+	handlers map[string][]func(Event)
+}
+
+// NewHandler returns a handler accepting the events signed with secret, the
+// secret of the webhook subscription. The secret cannot be empty: events are
+// never accepted without a valid signature.
+func NewHandler(secret string) (*Handler, error) {
+	if secret == "" {
+		return nil, errors.New("webhook secret is empty")
+	}
+	return &Handler{secret: []byte(secret), handlers: make(map[string][]func(Event))}, nil
+}
+
+// On registers a function called with the events about entity with the given
+// action.
+func (h *Handler) On(entity, action string, fn func(Event)) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	key := entity + "/" + action
+	h.handlers[key] = append(h.handlers[key], fn)
+}
+
+// OnTimeEntry registers a function called with the decoded time entry events
+// with the given action.
+func (h *Handler) OnTimeEntry(action string, fn func(TimeEntryEvent)) {
+	h.On(TimeEntry, action, func(e Event) {
+		var entry toggl.TimeEntry
+		if err := json.Unmarshal(e.Payload, &entry); err != nil {
+			h.logError(e, err)
+			return
+		}
+		fn(TimeEntryEvent{Event: e, TimeEntry: entry})
+	})
+}
+
+// OnProject registers a function called with the decoded project events with
+// the given action.
+func (h *Handler) OnProject(action string, fn func(ProjectEvent)) {
+	h.On(Project, action, func(e Event) {
+		var project toggl.Project
+		if err := json.Unmarshal(e.Payload, &project); err != nil {
+			h.logError(e, err)
+			return
+		}
+		fn(ProjectEvent{Event: e, Project: project})
+	})
+}
+
+func (h *Handler) logError(e Event, err error) {
+	if h.ErrorLog != nil {
+		h.ErrorLog(e, err)
+	}
+}
+
+// ServeHTTP verifies, decodes and dispatches an event.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxEventSize))
+	if err != nil {
+		http.Error(w, "error reading event", http.StatusBadRequest)
+		return
+	}
+
+	if !h.Verify(body, r.Header.Get(SignatureHeader)) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	var e Event
+	if err := json.Unmarshal(body, &e); err != nil {
+		http.Error(w, "invalid event", http.StatusBadRequest)
+		return
+	}
+
+	if e.ValidationCode != "" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"validation_code": e.ValidationCode})
+		return
+	}
+
+	if !e.IsPing() {
+		h.dispatch(e)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// Verify reports whether signature, as sent in the SignatureHeader header,
+// is the signature of body.
+func (h *Handler) Verify(body []byte, signature string) bool {
+	if len(h.secret) == 0 {
+		return false
+	}
+
+	sig, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, h.secret)
+	mac.Write(body)
+	return hmac.Equal(sig, mac.Sum(nil))
+}
+
+func (h *Handler) dispatch(e Event) {
+	h.mutex.RLock()
+	fns := append([]func(Event){}, h.handlers[e.Metadata.Model+"/"+e.Metadata.Action]...)
+	fns = append(fns, h.handlers[e.Metadata.Model+"/"+Any]...)
+	h.mutex.RUnlock()
+
+	for _, fn := range fns {
+		fn(e)
+	}
+}
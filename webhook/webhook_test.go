@@ -0,0 +1,45 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func TestNewHandlerEmptySecret(t *testing.T) {
+	if _, err := NewHandler(""); err == nil {
+		t.Error("NewHandler accepted an empty secret")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"event_id":1,"payload":"ping"}`)
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(body)
+	signature := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	h, err := NewHandler("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		h         *Handler
+		signature string
+		want      bool
+	}{
+		{h, signature, true},
+		{h, "", false},
+		{h, "sha256=00", false},
+		{h, "sha256=zz", false},
+		{&Handler{}, "", false},
+		{&Handler{}, signature, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.h.Verify(body, tt.signature); got != tt.want {
+			t.Errorf("Verify(%q) with secret %q = %v, want %v", tt.signature, tt.h.secret, got, tt.want)
+		}
+	}
+}